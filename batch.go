@@ -0,0 +1,167 @@
+package classifier
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// Item is a document to be classified by ClassifyBatch or ClassifyStream
+type Item struct {
+	// ID identifies the document within its results
+	ID string
+	// Doc provides the document text
+	Doc io.Reader
+}
+
+// Result is the classification of an Item
+type Result struct {
+	// ID is the ID of the classified Item
+	ID string
+	// Category is the classification of the document, if Err is nil
+	Category string
+	// Err is the error returned when classifying the document
+	Err error
+}
+
+// BatchOption provides configuration settings for batch classification
+type BatchOption func(*batch)
+
+type batch struct {
+	workers int
+}
+
+// Workers sets the number of documents that are classified concurrently,
+// which defaults to GOMAXPROCS
+func Workers(n int) BatchOption {
+	return func(b *batch) {
+		if n > 0 {
+			b.workers = n
+		}
+	}
+}
+
+// ClassifyBatch classifies the documents on a bounded pool of workers and
+// returns their results in the same order. Errors are reported per Result, so
+// a document that cannot be classified does not prevent the others from being
+// classified. Documents that were not classified before the context was
+// cancelled report the error of the context. The Classifier must be safe for
+// concurrent use, as the classifiers of this module are.
+func ClassifyBatch(ctx context.Context, c Classifier, items []Item, opts ...BatchOption) []Result {
+	in := make(chan Item)
+	go func() {
+		defer close(in)
+		for _, item := range items {
+			select {
+			case in <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := make([]Result, 0, len(items))
+	for result := range ClassifyStream(ctx, c, in, opts...) {
+		results = append(results, result)
+	}
+	for _, item := range items[len(results):] {
+		results = append(results, Result{ID: item.ID, Err: ctx.Err()})
+	}
+	return results
+}
+
+// ClassifyStream classifies the documents received from items on a bounded pool
+// of workers and emits their results in the order that the documents were
+// received. The results channel is closed once items is closed and every
+// document has been classified, or once the context is cancelled and the
+// workers have stopped. Classifiers that implement ContextClassifier stop
+// promptly on cancellation. After cancellation, items is no longer read, so its
+// producer should also observe the context. At most a few documents per worker
+// are held in memory while waiting for an earlier document to be classified.
+func ClassifyStream(ctx context.Context, c Classifier, items <-chan Item, opts ...BatchOption) <-chan Result {
+	b := &batch{workers: runtime.GOMAXPROCS(0)}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	type job struct {
+		item   Item
+		result chan Result
+	}
+	jobs := make(chan job)
+	pending := make(chan chan Result, 2*b.workers)
+	results := make(chan Result)
+
+	var wg sync.WaitGroup
+	for w := 0; w < b.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				category, err := classify(ctx, c, j.item.Doc)
+				j.result <- Result{ID: j.item.ID, Category: category, Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		for {
+			var item Item
+			select {
+			case next, ok := <-items:
+				if !ok {
+					return
+				}
+				item = next
+			case <-ctx.Done():
+				return
+			}
+
+			j := job{item: item, result: make(chan Result, 1)}
+			select {
+			case pending <- j.result:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer close(results)
+		defer wg.Wait()
+		for result := range pending {
+			select {
+			case r := <-result:
+				select {
+				case results <- r:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+// classify classifies the document with the context when the classifier
+// supports it
+func classify(ctx context.Context, c Classifier, r io.Reader) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if cc, ok := c.(ContextClassifier); ok {
+		return cc.ClassifyContext(ctx, r)
+	}
+	return c.Classify(r)
+}
@@ -0,0 +1,153 @@
+package classifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/n3integration/classifier/internal/leaktest"
+)
+
+var errEmptyDocument = errors.New("empty document")
+
+// echoClassifier classifies documents as their own text after a delay that
+// decreases with each document, so that later documents finish first
+type echoClassifier struct {
+	active, peak int32
+}
+
+func (e *echoClassifier) Train(io.Reader, string) error {
+	return nil
+}
+
+func (e *echoClassifier) TrainString(string, string) error {
+	return nil
+}
+
+func (e *echoClassifier) Classify(r io.Reader) (string, error) {
+	active := atomic.AddInt32(&e.active, 1)
+	defer atomic.AddInt32(&e.active, -1)
+	for peak := atomic.LoadInt32(&e.peak); active > peak; peak = atomic.LoadInt32(&e.peak) {
+		if atomic.CompareAndSwapInt32(&e.peak, peak, active) {
+			break
+		}
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if len(b) == 0 {
+		return "", errEmptyDocument
+	}
+	var n int
+	fmt.Sscanf(string(b), "doc%d", &n)
+	time.Sleep(time.Duration(20-n%20) * time.Millisecond)
+	return string(b), nil
+}
+
+func (e *echoClassifier) ClassifyString(doc string) (string, error) {
+	return e.Classify(strings.NewReader(doc))
+}
+
+func TestClassifyBatch(t *testing.T) {
+	items := make([]Item, 50)
+	for i := range items {
+		doc := fmt.Sprintf("doc%d", i)
+		if i%7 == 0 {
+			doc = ""
+		}
+		items[i] = Item{ID: fmt.Sprint(i), Doc: strings.NewReader(doc)}
+	}
+
+	c := &echoClassifier{}
+	results := ClassifyBatch(context.Background(), c, items, Workers(4))
+	if len(results) != len(items) {
+		t.Fatalf("expected %d results; got %d", len(items), len(results))
+	}
+	for i, result := range results {
+		if result.ID != fmt.Sprint(i) {
+			t.Fatalf("expected results in input order; got ID %s at %d", result.ID, i)
+		}
+		if i%7 == 0 {
+			if result.Err != errEmptyDocument {
+				t.Errorf("expected error for item %d; got %v", i, result.Err)
+			}
+		} else if result.Err != nil || result.Category != fmt.Sprintf("doc%d", i) {
+			t.Errorf("expected doc%d; got %q (%v)", i, result.Category, result.Err)
+		}
+	}
+	if c.peak > 4 {
+		t.Errorf("expected at most 4 concurrent classifications; got %d", c.peak)
+	}
+}
+
+func TestClassifyStream(t *testing.T) {
+	items := make(chan Item)
+	results := ClassifyStream(context.Background(), &echoClassifier{}, items, Workers(3))
+
+	go func() {
+		defer close(items)
+		for i := 0; i < 30; i++ {
+			items <- Item{ID: fmt.Sprint(i), Doc: strings.NewReader(fmt.Sprintf("doc%d", i))}
+		}
+	}()
+
+	i := 0
+	for result := range results {
+		if result.ID != fmt.Sprint(i) || result.Category != fmt.Sprintf("doc%d", i) {
+			t.Fatalf("expected doc%d; got %+v", i, result)
+		}
+		i++
+	}
+	if i != 30 {
+		t.Errorf("expected 30 results; got %d", i)
+	}
+}
+
+func TestClassifyStreamCancel(t *testing.T) {
+	defer leaktest.Check(t)()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	items := make(chan Item)
+	go func() {
+		defer close(items)
+		for i := 0; ; i++ {
+			select {
+			case items <- Item{ID: fmt.Sprint(i), Doc: strings.NewReader(fmt.Sprintf("doc%d", i))}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := ClassifyStream(ctx, &echoClassifier{}, items, Workers(4))
+	for i := 0; i < 3; i++ {
+		if result := <-results; result.ID != fmt.Sprint(i) {
+			t.Fatalf("expected result %d; got %+v", i, result)
+		}
+	}
+	// stop reading results without draining them
+	cancel()
+}
+
+func TestClassifyBatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items := []Item{{ID: "1", Doc: strings.NewReader("doc1")}, {ID: "2", Doc: strings.NewReader("doc2")}}
+	results := ClassifyBatch(ctx, &echoClassifier{}, items)
+	if len(results) != len(items) {
+		t.Fatalf("expected %d results; got %d", len(items), len(results))
+	}
+	for i, result := range results {
+		if result.ID != items[i].ID || result.Err != context.Canceled {
+			t.Errorf("expected a cancelled result for item %s; got %+v", items[i].ID, result)
+		}
+	}
+}
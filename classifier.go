@@ -1,6 +1,30 @@
 package classifier
 
-import "io"
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrTokenizerMismatch indicates that a persisted model was trained with a
+// different tokenizer configuration than the one it is being loaded with
+var ErrTokenizerMismatch = errors.New("tokenizer does not match the persisted model")
+
+var (
+	// ErrMissingDocumentID indicates that an identified training document has an empty ID
+	ErrMissingDocumentID = errors.New("document ID is required")
+	// ErrDuplicateDocument indicates that a document with the same ID has already been trained
+	ErrDuplicateDocument = errors.New("document has already been trained")
+)
+
+// Document identifies a training example, so that the results of a classifier
+// can be traced back to the records that it was trained with
+type Document struct {
+	// ID uniquely identifies the document within a classifier
+	ID string
+	// Metadata provides arbitrary attributes of the document
+	Metadata map[string]string
+}
 
 // Classifier provides a simple interface for different text classifiers
 type Classifier interface {
@@ -15,12 +39,23 @@ type Classifier interface {
 	ClassifyString(string) (string, error)
 }
 
-// WordCounts extracts term frequencies from a text corpus
+// ContextClassifier is a Classifier that stops training and classification
+// once a context is cancelled, returning the error of the context
+type ContextClassifier interface {
+	Classifier
+	// TrainContext allows clients to train the classifier until the context is cancelled
+	TrainContext(context.Context, io.Reader, string) error
+	// ClassifyContext performs a classification until the context is cancelled
+	ClassifyContext(context.Context, io.Reader) (string, error)
+}
+
+// WordCounts extracts term frequencies from a text corpus, along with the error
+// of the reader, if any
 func WordCounts(r io.Reader) (map[string]int, error) {
-	instream := NewTokenizer().Tokenize(r)
+	instream, wait := TokenizeChecked(context.Background(), NewTokenizer(), r)
 	wc := make(map[string]int)
 	for token := range instream {
 		wc[token] = wc[token] + 1
 	}
-	return wc, nil
+	return wc, wait()
 }
@@ -1,9 +1,18 @@
 package classifier
 
 import (
+	"errors"
+	"io"
 	"testing"
 )
 
+func TestWordCountsError(t *testing.T) {
+	errRead := errors.New("read failed")
+	if _, err := WordCounts(io.MultiReader(toReader(text), failingReader{errRead})); err != errRead {
+		t.Errorf("expected the error of the reader; got %v", err)
+	}
+}
+
 func TestWordCounts(t *testing.T) {
 	wc, err := WordCounts(toReader(text))
 
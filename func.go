@@ -1,5 +1,7 @@
 package classifier
 
+import "context"
+
 const defaultBufferSize = 50
 
 // Predicate provides a predicate function
@@ -10,16 +12,27 @@ type Mapper func(string) string
 
 // Map applies f to each element of the supplied input channel
 func Map(vs chan string, f ...Mapper) chan string {
+	return MapContext(context.Background(), vs, f...)
+}
+
+// MapContext applies f to each element of the supplied input channel until the
+// context is cancelled, at which point the output channel is closed. The
+// producer of the input channel should observe the same context.
+func MapContext(ctx context.Context, vs chan string, f ...Mapper) chan string {
 	stream := make(chan string, defaultBufferSize)
 
 	go func() {
+		defer close(stream)
 		for v := range vs {
 			for _, fn := range f {
 				v = fn(v)
 			}
-			stream <- v
+			select {
+			case stream <- v:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(stream)
 	}()
 
 	return stream
@@ -29,6 +42,14 @@ func Map(vs chan string, f ...Mapper) chan string {
 // is satisfied
 // Filter is a Predicate aggregation
 func Filter(vs chan string, filters ...Predicate) chan string {
+	return FilterContext(context.Background(), vs, filters...)
+}
+
+// FilterContext removes elements from the input channel where the supplied
+// predicate is satisfied until the context is cancelled, at which point the
+// output channel is closed. The producer of the input channel should observe
+// the same context.
+func FilterContext(ctx context.Context, vs chan string, filters ...Predicate) chan string {
 	stream := make(chan string, defaultBufferSize)
 	apply := func(text string) bool {
 		for _, f := range filters {
@@ -40,13 +61,18 @@ func Filter(vs chan string, filters ...Predicate) chan string {
 	}
 
 	go func() {
+		defer close(stream)
 		for text := range vs {
-			if apply(text) {
-				stream <- text
+			if !apply(text) {
+				continue
+			}
+			select {
+			case stream <- text:
+			case <-ctx.Done():
+				return
 			}
 		}
-		close(stream)
 	}()
 
 	return stream
-}
\ No newline at end of file
+}
@@ -1,7 +1,11 @@
 package index
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"sync"
 )
 
@@ -9,6 +13,7 @@ import (
 type TermIndex struct {
 	index int
 	terms map[string]*termRef
+	names []string
 	sync.RWMutex
 }
 
@@ -31,10 +36,22 @@ func (i *TermIndex) Add(t string) int {
 		1,
 		i.index,
 	}
+	i.names = append(i.names, t)
 	i.index++
 	return i.terms[t].index
 }
 
+// Remove decrements the frequency of a term and returns its new frequency. The
+// term retains its index, so that existing references to the index remain valid.
+func (i *TermIndex) Remove(t string) float64 {
+	i.Lock()
+	defer i.Unlock()
+	if ref, ok := i.terms[t]; ok {
+		return ref.decr()
+	}
+	return 0
+}
+
 // IndexOf returns the index of the provided term, or -1 if not found
 func (i *TermIndex) IndexOf(term string) int {
 	i.RLock()
@@ -45,6 +62,16 @@ func (i *TermIndex) IndexOf(term string) int {
 	return -1
 }
 
+// Term returns the term at the provided index, or false if not found
+func (i *TermIndex) Term(index int) (string, bool) {
+	i.RLock()
+	defer i.RUnlock()
+	if index < 0 || index >= len(i.names) {
+		return "", false
+	}
+	return i.names[index], true
+}
+
 // Frequency returns the term frequency within the index
 func (i *TermIndex) Frequency(term string) float64 {
 	i.RLock()
@@ -62,6 +89,68 @@ func (i *TermIndex) Count() int {
 	return len(i.terms)
 }
 
+// MarshalBinary encodes the index as a term count followed by each term, in
+// index order, as a length prefixed string and its frequency
+func (i *TermIndex) MarshalBinary() ([]byte, error) {
+	i.RLock()
+	defer i.RUnlock()
+
+	terms := make([]string, 0, len(i.terms))
+	for term := range i.terms {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(a, b int) bool {
+		return i.terms[terms[a]].index < i.terms[terms[b]].index
+	})
+
+	buf := binary.AppendUvarint(nil, uint64(len(terms)))
+	for _, term := range terms {
+		buf = binary.AppendUvarint(buf, uint64(len(term)))
+		buf = append(buf, term...)
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(i.terms[term].freq))
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary replaces the contents of the index with an index encoded by
+// MarshalBinary
+func (i *TermIndex) UnmarshalBinary(data []byte) error {
+	errCorrupt := errors.New("corrupt term index")
+	count, n := binary.Uvarint(data)
+	// every term occupies at least a length byte and an 8 byte frequency
+	if n <= 0 || count > uint64(len(data)-n)/9 {
+		return errCorrupt
+	}
+	data = data[n:]
+
+	terms := make(map[string]*termRef, count)
+	names := make([]string, 0, count)
+	for j := 0; j < int(count); j++ {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < size+8 {
+			return errCorrupt
+		}
+		term := string(data[n : n+int(size)])
+		data = data[n+int(size):]
+		terms[term] = &termRef{
+			math.Float64frombits(binary.LittleEndian.Uint64(data)),
+			j,
+		}
+		names = append(names, term)
+		data = data[8:]
+	}
+	if len(terms) != len(names) {
+		return errCorrupt
+	}
+
+	i.Lock()
+	defer i.Unlock()
+	i.terms = terms
+	i.names = names
+	i.index = len(terms)
+	return nil
+}
+
 func (i *TermIndex) String() string {
 	i.RLock()
 	defer i.RUnlock()
@@ -79,6 +168,13 @@ func (t *termRef) incr() float64 {
 	return t.freq
 }
 
+func (t *termRef) decr() float64 {
+	if t.freq > 0 {
+		t.freq--
+	}
+	return t.freq
+}
+
 func (t *termRef) String() string {
 	return fmt.Sprintf("%v", t.freq)
 }
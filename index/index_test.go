@@ -23,5 +23,64 @@ func TestTermIndex(t *testing.T) {
 		if index.Frequency(term) < 1 {
 			t.Errorf("incorrect frequency; expected %v, but got %v", expected, index.Frequency(term))
 		}
+		if actual, ok := index.Term(index.IndexOf(term)); !ok || actual != term {
+			t.Errorf("incorrect term; expected %v, but got %v", term, actual)
+		}
+	}
+	if _, ok := index.Term(allTermsExpected); ok {
+		t.Error("expected term lookup beyond the index to fail")
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	index := NewTermIndex(expected)
+	for _, txt := range strings.Split(text, " ") {
+		index.Add(strings.ToLower(txt))
+	}
+
+	data, err := index.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := NewTermIndex(0)
+	if err := other.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if other.Count() != index.Count() {
+		t.Errorf("incorrect index size; expected %v, but got %v", index.Count(), other.Count())
+	}
+	for term := range index.terms {
+		name, _ := other.Term(index.IndexOf(term))
+		if other.IndexOf(term) != index.IndexOf(term) || other.Frequency(term) != index.Frequency(term) || name != term {
+			t.Errorf("term %q was not restored", term)
+		}
+	}
+	if other.Add("cat") != index.Count() {
+		t.Error("expected new terms to be appended to the restored index")
+	}
+
+	if err := other.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("expected an error for a truncated index")
+	}
+	corrupt := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}
+	if err := other.UnmarshalBinary(corrupt); err == nil {
+		t.Error("expected an error for a term count that exceeds the data")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	index := NewTermIndex(expected)
+	index.Add("fox")
+	index.Add("fox")
+
+	if freq := index.Remove("fox"); freq != 1 {
+		t.Errorf("incorrect frequency; expected 1, but got %v", freq)
+	}
+	if freq := index.Remove("fox"); freq != 0 || index.Remove("fox") != 0 {
+		t.Errorf("incorrect frequency; expected 0, but got %v", freq)
+	}
+	if index.IndexOf("fox") != 0 || index.Remove("dog") != 0 {
+		t.Error("expected removed terms to retain their index")
 	}
 }
@@ -0,0 +1,16 @@
+// Package funcname identifies functions by name, so that pluggable strategies
+// can be compared and persisted
+package funcname
+
+import (
+	"reflect"
+	"runtime"
+)
+
+// Of returns the fully qualified name of the function fn
+func Of(fn interface{}) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "unknown"
+}
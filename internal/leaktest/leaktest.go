@@ -0,0 +1,42 @@
+// Package leaktest provides helpers for tests that verify that cancelled
+// tokenization and classification do not leak goroutines
+package leaktest
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// timeout bounds the time that goroutines are given to exit
+const timeout = 5 * time.Second
+
+// Endless provides an infinite document of repeated words
+type Endless struct{}
+
+func (Endless) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = "word "[i%5]
+	}
+	return len(p) - len(p)%5, nil
+}
+
+// Check records the number of running goroutines and returns a function that
+// waits for the number to return to it, failing the test if it does not.
+// Typical usage is:
+//
+//	defer leaktest.Check(t)()
+func Check(tb testing.TB) func() {
+	before := runtime.NumGoroutine()
+	return func() {
+		tb.Helper()
+		deadline := time.Now().Add(timeout)
+		for runtime.NumGoroutine() > before {
+			if time.Now().After(deadline) {
+				buf := make([]byte, 1<<16)
+				tb.Fatalf("expected %d goroutines; got %d\n%s", before, runtime.NumGoroutine(), buf[:runtime.Stack(buf, true)])
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package knn
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/n3integration/classifier/internal/funcname"
+)
+
+// postings provides an inverted index from each feature of the matrix to the
+// rows that contain it, in ascending row order
+type postings struct {
+	rows [][]int
+	// maxWeight holds the largest weight of each feature within any row,
+	// relative to the magnitude of the row; it bounds the contribution of the
+	// feature to the cosine similarity of any row
+	maxWeight []float64
+	// negative is set once any row has a negative term weight, which makes
+	// retrieval from the inverted index inexact
+	negative bool
+}
+
+// newPostings builds the inverted index of an existing matrix
+func newPostings(m *sparse) *postings {
+	p := &postings{}
+	next := m.Rows()
+	for row := next(); row != nil; row = next() {
+		p.add(row)
+	}
+	return p
+}
+
+// add appends a row to the inverted index; rows must be added in ascending order
+func (p *postings) add(row *sparseRow) {
+	norm := row.L2Norm()
+	for i := 0; i < row.Len(); i++ {
+		feature, val := row.Column(i)
+		for feature >= len(p.rows) {
+			p.rows = append(p.rows, nil)
+			p.maxWeight = append(p.maxWeight, 0)
+		}
+		p.rows[feature] = append(p.rows[feature], row.Index())
+		if val < 0 {
+			p.negative = true
+		}
+		if norm > 0 {
+			p.maxWeight[feature] = math.Max(p.maxWeight[feature], val/norm)
+		}
+	}
+}
+
+// posting returns the rows that contain feature
+func (p *postings) posting(feature int) []int {
+	if feature < 0 || feature >= len(p.rows) {
+		return nil
+	}
+	return p.rows[feature]
+}
+
+// overlapOnly reports whether rows without any features in common with the
+// document score zero and no other row scores less, which allows candidate
+// rows to be retrieved from the inverted index rather than scanning the entire
+// matrix. This holds for CosineSimilarity with non-negative term weights, but
+// not for PearsonCorrelation, which may be negative.
+func overlapOnly(s SimilarityScore) bool {
+	return funcname.Of(s) == funcname.Of(CosineSimilarity)
+}
+
+// fill completes results that hold fewer than k rows with the first live rows
+// that share no features with the document, as a scan of the matrix would.
+// Results with fewer than k rows hold every live row that shares a feature.
+// The caller must hold c.mu.
+func (c *Classifier) fill(this *sparseRow, results topResults) topResults {
+	if len(results) >= c.k {
+		return results
+	}
+
+	found := make(map[int]struct{}, len(results))
+	for _, result := range results {
+		found[result.Row] = struct{}{}
+	}
+	score := c.scorer(this)
+	for row := range c.categories {
+		if len(results) == c.k {
+			break
+		}
+		if _, ok := found[row]; ok || c.deleted[row] {
+			continue
+		}
+		results.offer(c.k, score(row))
+	}
+	return results
+}
+
+// candidates scores every live row that shares at least one feature with the
+// document and returns the k most similar, until the context is cancelled. The
+// caller must hold c.mu.
+func (c *Classifier) candidates(ctx context.Context, p *postings, this *sparseRow) topResults {
+	return c.partition(func(low, high int) topResults {
+		score := c.scorer(this)
+		seen := newRowSet(high - low)
+		results := make(topResults, 0, c.k)
+
+		for i, scored := 0, 0; i < this.Len(); i++ {
+			rows := p.posting(this.Feature(i))
+			for _, row := range rows[sort.SearchInts(rows, low):] {
+				if row >= high {
+					break
+				}
+				if !seen.visit(row-low) || c.deleted[row] {
+					continue
+				}
+				if scored%cancelInterval == 0 && ctx.Err() != nil {
+					return results
+				}
+				results.offer(c.k, score(row))
+				scored++
+			}
+		}
+		return results
+	})
+}
+
+// rowSet tracks the rows that have been visited using a single allocation
+type rowSet []uint64
+
+func newRowSet(rows int) rowSet {
+	return make(rowSet, (rows+63)/64)
+}
+
+// visit marks the row as visited and reports whether it was not visited before
+func (s rowSet) visit(row int) bool {
+	word, bit := row/64, uint64(1)<<(row%64)
+	if s[word]&bit != 0 {
+		return false
+	}
+	s[word] |= bit
+	return true
+}
+
+// maxScore returns the k rows with the greatest cosine similarity to the
+// document using MaxScore dynamic pruning. Features of the document are
+// ordered by their maximum possible contribution to the similarity of any row;
+// once the combined contribution of the weakest features can no longer reach
+// the k-th best score, rows that only contain those features are skipped. Term
+// weights are assumed to be non-negative. Scoring stops once the context is
+// cancelled. The caller must hold c.mu.
+func (c *Classifier) maxScore(ctx context.Context, p *postings, this *sparseRow) topResults {
+	norm := this.L2Norm()
+	score := c.scorer(this)
+	results := make(topResults, 0, c.k)
+	if norm == 0 {
+		return results
+	}
+
+	type cursor struct {
+		rows  []int
+		bound float64
+	}
+	cursors := make([]*cursor, 0, this.Len())
+	for i := 0; i < this.Len(); i++ {
+		feature, val := this.Column(i)
+		if rows := p.posting(feature); len(rows) > 0 {
+			cursors = append(cursors, &cursor{
+				rows:  rows,
+				bound: val / norm * p.maxWeight[feature],
+			})
+		}
+	}
+	sort.Slice(cursors, func(i, j int) bool {
+		return cursors[i].bound < cursors[j].bound
+	})
+
+	prefix := make([]float64, len(cursors)+1)
+	for i, cur := range cursors {
+		prefix[i+1] = prefix[i] + cur.bound
+	}
+
+	essential := 0
+	for scored := 0; ; scored++ {
+		if scored%cancelInterval == 0 && ctx.Err() != nil {
+			break
+		}
+		// rows that only contain non-essential features cannot exceed the threshold
+		if len(results) == c.k {
+			threshold := results[0].Score
+			for essential < len(cursors) && prefix[essential+1] <= threshold {
+				essential++
+			}
+		}
+
+		row := math.MaxInt
+		for _, cur := range cursors[essential:] {
+			if len(cur.rows) > 0 && cur.rows[0] < row {
+				row = cur.rows[0]
+			}
+		}
+		if row == math.MaxInt {
+			break
+		}
+		for _, cur := range cursors[essential:] {
+			if len(cur.rows) > 0 && cur.rows[0] == row {
+				cur.rows = cur.rows[1:]
+			}
+		}
+		if c.deleted[row] {
+			continue
+		}
+
+		results.offer(c.k, score(row))
+	}
+	return results
+}
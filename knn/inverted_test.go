@@ -0,0 +1,169 @@
+package knn
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/n3integration/classifier"
+)
+
+func TestCandidateRetrieval(t *testing.T) {
+	for _, k := range []int{1, 3, 5} {
+		knn := New(K(k), WeightScheme(classifier.TermFrequency), MaxScore())
+		loadTestData(t, knn)
+		knn.RemoveRow(0)
+
+		for _, headline := range headlines {
+			this := queryRow(t, knn, headline)
+			scanned := knn.scan(context.Background(), this)
+			sort.Sort(scanned)
+
+			candidates := knn.candidates(context.Background(), knn.search.(*exact).inverted(), this)
+			sort.Sort(candidates)
+			pruned := knn.maxScore(context.Background(), knn.search.(*exact).inverted(), this)
+			sort.Sort(pruned)
+
+			overlapping := 0
+			for _, result := range scanned {
+				if result.Score > 0 {
+					overlapping++
+				}
+			}
+			n := k
+			if overlapping < k {
+				n = overlapping
+			}
+			if len(candidates) != n {
+				t.Errorf("expected %d candidates; got %d", n, len(candidates))
+			}
+			if len(pruned) != n {
+				t.Fatalf("expected %d results; got %d", n, len(pruned))
+			}
+			for i := 1; i <= n; i++ {
+				assertEquivalent(t, pruned[len(pruned)-i].Score, scanned[len(scanned)-i].Score, 1e-12)
+				assertEquivalent(t, candidates[len(candidates)-i].Score, scanned[len(scanned)-i].Score, 1e-12)
+			}
+			for _, result := range append(candidates, pruned...) {
+				if result.Row == 0 {
+					t.Error("expected removed rows to be excluded")
+				}
+			}
+		}
+	}
+}
+
+func TestExactMatchesScan(t *testing.T) {
+	knn := New(K(1), Similarity(PearsonCorrelation), WeightScheme(classifier.BagOfWords))
+	knn.TrainString("alpha alpha alpha beta", "neg")
+	knn.TrainString("gamma delta", "zero")
+	if category, err := knn.ClassifyString("alpha beta beta beta"); err != nil || category != "zero" {
+		t.Errorf("expected the uncorrelated row to outscore the negatively correlated row; got %q (%v)", category, err)
+	}
+
+	similarities := map[string]SimilarityScore{
+		"Cosine":    CosineSimilarity,
+		"Euclidean": EuclideanDistance,
+		"Pearson":   PearsonCorrelation,
+	}
+	for name, similarity := range similarities {
+		for _, k := range []int{1, 5, 50} {
+			for _, retrieval := range []Option{nil, InvertedIndex(), MaxScore()} {
+				opts := []Option{K(k), WeightScheme(classifier.TermFrequency), Similarity(similarity)}
+				if retrieval != nil {
+					opts = append(opts, retrieval)
+				}
+				knn := New(opts...)
+				loadTestData(t, knn)
+				knn.RemoveRow(0)
+
+				for _, headline := range append(headlines, "unseen words only") {
+					this := queryRow(t, knn, headline)
+					scanned := knn.scan(context.Background(), this)
+					sort.Sort(scanned)
+					searched := knn.search.search(context.Background(), knn, this)
+					sort.Sort(searched)
+					if overlapOnly(similarity) {
+						candidates := knn.fill(this, knn.candidates(context.Background(), knn.search.(*exact).inverted(), this))
+						sort.Sort(candidates)
+						assertSameScores(t, name, candidates, scanned)
+					}
+					assertSameScores(t, name, searched, scanned)
+				}
+			}
+		}
+	}
+}
+
+func TestNegativeWeights(t *testing.T) {
+	centered := func(doc map[string]float64) classifier.WeightScheme {
+		return func(term string) float64 {
+			return doc[term] - 2
+		}
+	}
+
+	for _, retrieval := range []Option{InvertedIndex(), MaxScore()} {
+		knn := New(K(1), WeightScheme(centered), retrieval)
+		knn.TrainString("alpha", "neg")
+		knn.TrainString("gamma", "other")
+		if category, err := knn.ClassifyString("alpha alpha alpha"); err != nil || category != "other" {
+			t.Errorf("expected the unrelated row to outscore the negative cosine row; got %q (%v)", category, err)
+		}
+	}
+}
+
+func assertSameScores(t *testing.T, name string, actual, expected topResults) {
+	t.Helper()
+	if len(actual) != len(expected) {
+		t.Fatalf("%s: expected %d results; got %d", name, len(expected), len(actual))
+	}
+	for i := range expected {
+		if math.Abs(actual[i].Score-expected[i].Score) > 1e-12 {
+			t.Fatalf("%s: expected scores %v; got %v", name, expected, actual)
+		}
+	}
+}
+
+func TestMaxScoreRandomized(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	word := func() string {
+		return fmt.Sprintf("term%c%c", 'a'+rnd.Intn(8), 'a'+rnd.Intn(8))
+	}
+	doc := func(n int) string {
+		words := make([]string, n)
+		for i := range words {
+			words[i] = word()
+		}
+		return strings.Join(words, " ")
+	}
+
+	knn := New(K(5), WeightScheme(classifier.LogNorm), MaxScore())
+	for i := 0; i < 500; i++ {
+		knn.TrainString(doc(5+rnd.Intn(20)), fmt.Sprint(i%3))
+	}
+
+	for i := 0; i < 50; i++ {
+		this := queryRow(t, knn, doc(4))
+		candidates := knn.candidates(context.Background(), knn.search.(*exact).inverted(), this)
+		sort.Sort(candidates)
+		pruned := knn.maxScore(context.Background(), knn.search.(*exact).inverted(), this)
+		sort.Sort(pruned)
+
+		for j := 1; j <= len(pruned); j++ {
+			assertEquivalent(t, pruned[len(pruned)-j].Score, candidates[len(candidates)-j].Score, 1e-12)
+		}
+	}
+}
+
+// queryRow vectorizes the document like a classified document
+func queryRow(tb testing.TB, knn *Classifier, doc string) *sparseRow {
+	wordFreq, err := knn.termFrequencies(context.Background(), asReader(doc))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return knn.matrix.MakeRow(knn.index, knn.weightScheme, wordFreq)
+}
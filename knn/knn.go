@@ -2,10 +2,10 @@ package knn
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"math"
 	"sort"
 	"sync"
 
@@ -16,6 +16,23 @@ import (
 const (
 	defaultKVal          = 1
 	defaultIndexCapacity = 10_000
+	// cancelInterval is the number of rows that are scored between checks for
+	// the cancellation of a context
+	cancelInterval = 1024
+)
+
+var (
+	_ classifier.ProbabilisticClassifier = (*Classifier)(nil)
+	_ classifier.ContextClassifier       = (*Classifier)(nil)
+)
+
+var (
+	// ErrNotClassified indicates that a document could not be classified
+	ErrNotClassified = errors.New("unable to classify document")
+	// ErrEmptyDocument indicates that a training document does not contain any
+	// terms, such as a single word document with a bigram tokenizer, and would
+	// never be similar to any other document
+	ErrEmptyDocument = errors.New("document does not contain any terms")
 )
 
 // Option provides a functional setting for the Classifier
@@ -27,29 +44,64 @@ type Classifier struct {
 
 	k            int
 	categories   []string
+	documents    []classifier.Document
+	rows         map[string]int
+	deleted      []bool
+	tombstones   int
+	compactRatio float64
 	index        *index.TermIndex
+	inverted     bool
 	matrix       *sparse
+	pruning      bool
+	search       Searcher
 	similarity   SimilarityScore
+	threshold    classifier.Threshold
 	tokenizer    classifier.Tokenizer
+	voting       VotingStrategy
 	weightScheme classifier.WeightSchemeStrategy
+	workers      int
+	release      func() error
 }
 
 // New initializes a new k-nearest neighbor classifier unless overridden,
-// binary term weights and k=1 will be used for the created instance
+// binary term weights and k=1 will be used for the created instance. Invalid
+// options are ignored; use NewClassifier to handle their errors.
 func New(opts ...Option) *Classifier {
-	c := &Classifier{
+	c := newClassifier()
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewClassifier initializes a new k-nearest neighbor classifier like New, or
+// returns the error of the first invalid option
+func NewClassifier(opts ...Option) (*Classifier, error) {
+	c := newClassifier()
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func newClassifier() *Classifier {
+	return &Classifier{
 		k:            defaultKVal,
 		categories:   make([]string, 0),
+		documents:    make([]classifier.Document, 0),
+		rows:         make(map[string]int),
+		deleted:      make([]bool, 0),
 		index:        index.NewTermIndex(defaultIndexCapacity),
 		matrix:       newSparseMatrix(),
+		search:       Exact(),
 		similarity:   CosineSimilarity,
 		tokenizer:    classifier.NewTokenizer(),
+		voting:       MajorityVoting,
 		weightScheme: classifier.Binary,
+		workers:      1,
 	}
-	for _, opt := range opts {
-		opt(c)
-	}
-	return c
 }
 
 // K provides the value of 'k'
@@ -63,6 +115,49 @@ func K(k int) Option {
 	}
 }
 
+// MinConfidence sets the minimum share of the k nearest neighbors that must agree
+// on a category. Less certain classifications return classifier.ErrLowConfidence.
+func MinConfidence(p float64) Option {
+	return func(c *Classifier) error {
+		if p < 0 || p > 1 {
+			return errors.New("the minimum confidence must be between 0 and 1")
+		}
+		c.threshold.MinConfidence = p
+		return nil
+	}
+}
+
+// MinMargin sets the minimum difference required between the scores of the two
+// most common categories among the k nearest neighbors. Less certain
+// classifications return classifier.ErrLowConfidence.
+func MinMargin(m float64) Option {
+	return func(c *Classifier) error {
+		if m < 0 || m > 1 {
+			return errors.New("the minimum margin must be between 0 and 1")
+		}
+		c.threshold.MinMargin = m
+		return nil
+	}
+}
+
+// Fallback provides a label that is returned in place of classifier.ErrLowConfidence
+func Fallback(category string) Option {
+	return func(c *Classifier) error {
+		c.threshold.Fallback = category
+		return nil
+	}
+}
+
+// Voting provides an alternate strategy for weighting the votes of the k nearest
+// neighbors. Ties between categories are broken in favor of the category whose
+// neighbors have the greater combined similarity, and then by category name.
+func Voting(v VotingStrategy) Option {
+	return func(c *Classifier) error {
+		c.voting = v
+		return nil
+	}
+}
+
 // WeightScheme provides the term weight scheme
 func WeightScheme(s classifier.WeightSchemeStrategy) Option {
 	return func(c *Classifier) error {
@@ -79,6 +174,43 @@ func Similarity(s SimilarityScore) Option {
 	}
 }
 
+// InvertedIndex retrieves the rows that share a term with the document from an
+// inverted index when using CosineSimilarity, rather than scanning every row.
+// The index is built on the heap when the first document is classified, and is
+// about the size of the terms of the matrix. Rows are scanned whenever a
+// negative term weight has been seen, as retrieval is only exact for
+// non-negative weights.
+func InvertedIndex() Option {
+	return func(c *Classifier) error {
+		c.inverted = true
+		return nil
+	}
+}
+
+// MaxScore enables MaxScore dynamic pruning of the rows retrieved from the
+// inverted index when using CosineSimilarity, which skips rows that cannot be
+// among the k nearest neighbors. It implies InvertedIndex.
+func MaxScore() Option {
+	return func(c *Classifier) error {
+		c.inverted = true
+		c.pruning = true
+		return nil
+	}
+}
+
+// Search provides an alternate nearest neighbor search strategy, such as the
+// approximate SimHash search. A Searcher must not be shared between classifiers.
+func Search(s Searcher) Option {
+	return func(c *Classifier) error {
+		if err := s.validate(); err != nil {
+			return err
+		}
+		c.search = s
+		c.search.reset(c.matrix)
+		return nil
+	}
+}
+
 // Tokenizer provides an alternate document Tokenizer
 func Tokenizer(t classifier.Tokenizer) Option {
 	return func(c *Classifier) error {
@@ -100,20 +232,52 @@ func (c *Classifier) TrainString(doc string, category string) error {
 }
 
 func (c *Classifier) Train(r io.Reader, category string) error {
-	wordFreq := make(map[string]float64)
-	for text := range c.tokenizer.Tokenize(r) {
-		count := wordFreq[text]
-		wordFreq[text] = count + 1
+	return c.TrainContext(context.Background(), r, category)
+}
 
-		if count == 0 {
-			c.index.Add(text)
-		}
+// TrainContext provides supervisory training to the classifier unless the
+// context is cancelled before the document has been tokenized, in which case
+// the classifier is left unchanged and the error of the context is returned
+func (c *Classifier) TrainContext(ctx context.Context, r io.Reader, category string) error {
+	return c.train(ctx, r, category, classifier.Document{})
+}
+
+// TrainDocument provides supervisory training to the classifier using an
+// identified document. The document ID must be unique and, along with the
+// document metadata, is surfaced by Neighbors.
+func (c *Classifier) TrainDocument(doc classifier.Document, r io.Reader, category string) error {
+	if doc.ID == "" {
+		return classifier.ErrMissingDocumentID
+	}
+	return c.train(context.Background(), r, category, doc)
+}
+
+func (c *Classifier) train(ctx context.Context, r io.Reader, category string, doc classifier.Document) error {
+	wordFreq, err := c.termFrequencies(ctx, r)
+	if err != nil {
+		return err
+	}
+	if len(wordFreq) == 0 {
+		return ErrEmptyDocument
 	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if doc.ID != "" {
+		if _, ok := c.rows[doc.ID]; ok {
+			return classifier.ErrDuplicateDocument
+		}
+		c.rows[doc.ID] = len(c.categories)
+	}
+
+	for text := range wordFreq {
+		c.index.Add(text)
+	}
 	c.categories = append(c.categories, category)
+	c.documents = append(c.documents, doc)
+	c.deleted = append(c.deleted, false)
 	c.matrix.Add(c.index, c.weightScheme(wordFreq), wordFreq)
+	c.search.add(c.matrix.Row(len(c.categories) - 1))
 	return nil
 }
 
@@ -121,31 +285,124 @@ func (c *Classifier) ClassifyString(doc string) (string, error) {
 	return c.Classify(asReader(doc))
 }
 
+// Classify returns the most common category among the k nearest neighbors of the
+// document. If the classifier has not been trained, or the document does not
+// contain any terms, ErrNotClassified is returned.
+// If the most common category does not satisfy the configured confidence
+// thresholds, classifier.ErrLowConfidence or the configured fallback label is
+// returned.
 func (c *Classifier) Classify(r io.Reader) (string, error) {
-	wordFreq := make(map[string]float64)
-	for text := range c.tokenizer.Tokenize(r) {
-		count := wordFreq[text]
-		wordFreq[text] = count + 1
+	return c.ClassifyContext(context.Background(), r)
+}
+
+// ClassifyContext classifies a document like Classify, but stops tokenizing and
+// searching for neighbors once the context is cancelled and returns the error
+// of the context
+func (c *Classifier) ClassifyContext(ctx context.Context, r io.Reader) (string, error) {
+	scores, err := c.scores(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	return c.threshold.Decide(scores)
+}
+
+// Scores returns the share of the weighted votes of the k nearest neighbors
+// that belong to each category, ordered from the most to the least common
+func (c *Classifier) Scores(r io.Reader) ([]classifier.Score, error) {
+	return c.scores(context.Background(), r)
+}
+
+func (c *Classifier) scores(ctx context.Context, r io.Reader) ([]classifier.Score, error) {
+	wordFreq, err := c.termFrequencies(ctx, r)
+	if err != nil {
+		return nil, err
 	}
 
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	this := c.matrix.MakeRow(c.index, c.weightScheme, wordFreq)
-	next := c.matrix.Rows()
-	results := make(topResults, 0)
+	_, results, err := c.nearest(ctx, wordFreq)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNotClassified
+	}
+	return results.vote(c.k, c.strategy()), nil
+}
+
+// ScoresString provides convenience scoring for strings
+func (c *Classifier) ScoresString(doc string) ([]classifier.Score, error) {
+	return c.Scores(asReader(doc))
+}
 
-	for row := next(); row != nil; row = next() {
-		results = append(results, &topResult{
-			Score:    c.similarity(row, this),
-			Category: c.categories[row.Index()],
-		})
+// termFrequencies tokenizes the document into term frequencies. The error of
+// the context or the tokenizer is returned if tokenization ended early.
+func (c *Classifier) termFrequencies(ctx context.Context, r io.Reader) (map[string]float64, error) {
+	wordFreq := make(map[string]float64)
+	tokens, wait := classifier.TokenizeChecked(ctx, c.tokenizer, r)
+	for text := range tokens {
+		count := wordFreq[text]
+		wordFreq[text] = count + 1
 	}
+	return wordFreq, wait()
+}
 
+// nearest scores the document against the training rows retrieved by the
+// configured Searcher and returns the document row along with the k most
+// similar results in ascending order of similarity. A document without any
+// terms has no neighbors. If the context is cancelled, the error of the context
+// is returned. The caller must hold c.mu.
+func (c *Classifier) nearest(ctx context.Context, wordFreq map[string]float64) (*sparseRow, topResults, error) {
+	this := c.matrix.MakeRow(c.index, c.weightScheme, wordFreq)
+	if this.Len() == 0 {
+		return this, nil, nil
+	}
+	results := c.search.search(ctx, c, this)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 	sort.Sort(results)
-	return results.query(c.k), nil
+	return this, results, nil
 }
 
-type topResults []*topResult
+// scan scores the document against every live training row until the context
+// is cancelled. The caller must hold c.mu.
+func (c *Classifier) scan(ctx context.Context, this *sparseRow) topResults {
+	return c.partition(func(low, high int) topResults {
+		score := c.scorer(this)
+		results := make(topResults, 0, c.k)
+
+		for row := low; row < high; row++ {
+			if (row-low)%cancelInterval == 0 && ctx.Err() != nil {
+				break
+			}
+			if c.deleted[row] {
+				continue
+			}
+			results.offer(c.k, score(row))
+		}
+		return results
+	})
+}
+
+// scorer returns a function that scores rows of the matrix against the
+// document. A single row view is reused across calls, so the function must not
+// be shared between goroutines.
+func (c *Classifier) scorer(this *sparseRow) func(row int) topResult {
+	view := &sparseRow{}
+	return func(row int) topResult {
+		c.matrix.view(row, view)
+		return topResult{
+			Row:      row,
+			Score:    c.similarity(view, this),
+			Category: c.categories[row],
+		}
+	}
+}
+
+// topResults holds the most similar results as a bounded min-heap, such that
+// the least similar result is always first
+type topResults []topResult
 
 func (r topResults) Len() int {
 	return len(r)
@@ -159,37 +416,56 @@ func (r topResults) Swap(i, j int) {
 	r[i], r[j] = r[j], r[i]
 }
 
-func (r topResults) topK(k int) map[string]int {
-	count := 0
-	topk := make(map[string]int)
-	for i := 1; i <= k; i++ {
-		count = topk[r[len(r)-i].Category]
-		topk[r[len(r)-i].Category] = count + 1
+// offer adds the result to the heap if fewer than k results are held or if it
+// is more similar than the least similar result, which it then replaces
+func (r *topResults) offer(k int, result topResult) {
+	h := *r
+	switch {
+	case len(h) < k:
+		h = append(h, result)
+		h.up(len(h) - 1)
+		*r = h
+	case len(h) > 0 && result.Score > h[0].Score:
+		h[0] = result
+		h.down(0)
 	}
-	return topk
 }
 
-func (r topResults) query(k int) string {
-	max := 0
-	var category string
-	topk := r.topK(int(math.Min(float64(k), float64(len(r)))))
-
-	for cat, count := range topk {
-		if count > max {
-			max = count
-			category = cat
+func (r topResults) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !r.Less(i, parent) {
+			break
 		}
+		r.Swap(i, parent)
+		i = parent
 	}
+}
 
-	return category
+func (r topResults) down(i int) {
+	for {
+		least := i
+		if left := 2*i + 1; left < len(r) && r.Less(left, least) {
+			least = left
+		}
+		if right := 2*i + 2; right < len(r) && r.Less(right, least) {
+			least = right
+		}
+		if least == i {
+			break
+		}
+		r.Swap(i, least)
+		i = least
+	}
 }
 
 type topResult struct {
+	Row      int
 	Score    float64
 	Category string
 }
 
-func (t *topResult) String() string {
+func (t topResult) String() string {
 	return fmt.Sprintf("%.2f", t.Score)
 }
 
@@ -1,12 +1,20 @@
 package knn
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"log"
+	"math/rand"
 	"os"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/n3integration/classifier"
+	"github.com/n3integration/classifier/internal/leaktest"
 )
 
 func TestClassifier(t *testing.T) {
@@ -20,25 +28,7 @@ func TestClassifier(t *testing.T) {
 		)),
 	)
 
-	dataDir, err := os.ReadDir("testdata")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	for _, file := range dataDir {
-		if file.IsDir() {
-			dir := file
-			files, rErr := os.ReadDir(fmt.Sprintf("testdata/%s", dir.Name()))
-			if rErr != nil {
-				log.Fatal(rErr)
-			}
-			for _, f := range files {
-				if lErr := load(knn, dir.Name(), fmt.Sprintf("testdata/%s/%s", dir.Name(), f.Name())); lErr != nil {
-					t.Fatal(lErr)
-				}
-			}
-		}
-	}
+	loadTestData(t, knn)
 
 	testdata := []struct {
 		Name             string
@@ -70,6 +60,28 @@ func TestClassifier(t *testing.T) {
 	}
 }
 
+func loadTestData(t testing.TB, knn *Classifier) {
+	dataDir, err := os.ReadDir("testdata")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, file := range dataDir {
+		if file.IsDir() {
+			dir := file
+			files, rErr := os.ReadDir(fmt.Sprintf("testdata/%s", dir.Name()))
+			if rErr != nil {
+				log.Fatal(rErr)
+			}
+			for _, f := range files {
+				if lErr := load(knn, dir.Name(), fmt.Sprintf("testdata/%s/%s", dir.Name(), f.Name())); lErr != nil {
+					t.Fatal(lErr)
+				}
+			}
+		}
+	}
+}
+
 func load(knn *Classifier, category, filename string) error {
 	f, err := os.Open(filename)
 	if err != nil {
@@ -78,3 +90,232 @@ func load(knn *Classifier, category, filename string) error {
 	defer f.Close()
 	return knn.Train(f, category)
 }
+
+func TestScores(t *testing.T) {
+	knn := newTestClassifier()
+	loadTestData(t, knn)
+
+	scores, err := knn.ScoresString(headlines[1])
+	if err != nil {
+		t.Fatal("failed to score headline:", err)
+	}
+	if len(scores) == 0 || scores[0].Category != "sports" {
+		t.Fatalf("expected sports to be ranked first; got %v", scores)
+	}
+
+	sum := 0.0
+	for i, score := range scores {
+		sum += score.Score
+		if i > 0 && score.Score > scores[i-1].Score {
+			t.Errorf("expected scores in descending order; got %v", scores)
+		}
+	}
+	assertEquivalent(t, sum, 1, 1e-9)
+}
+
+func TestInvalidOptions(t *testing.T) {
+	opts := []Option{K(0), MinConfidence(5), MinMargin(-1), AutoCompact(0), Workers(0), Search(SimHash(0, 8))}
+	for _, opt := range opts {
+		if c, err := NewClassifier(opt); err == nil || c != nil {
+			t.Errorf("expected an error for an invalid option; got %v", err)
+		}
+	}
+
+	if knn := New(K(0)); knn.k != defaultKVal {
+		t.Errorf("expected New to ignore an invalid option; got k=%d", knn.k)
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	if _, err := New().ClassifyString(headlines[0]); err != ErrNotClassified {
+		t.Errorf("expected untrained classifier to return ErrNotClassified; got %v", err)
+	}
+
+	knn := New(K(2), MinConfidence(0.75))
+	knn.TrainString("apple banana cherry", "fruit")
+	knn.TrainString("carrot celery banana", "vegetable")
+
+	if _, err := knn.ClassifyString("banana"); err != classifier.ErrLowConfidence {
+		t.Errorf("expected split vote to return ErrLowConfidence; got %v", err)
+	}
+
+	knn = New(K(2), MinMargin(0.5), Fallback("unknown"))
+	knn.TrainString("apple banana cherry", "fruit")
+	knn.TrainString("carrot celery banana", "vegetable")
+
+	if category, err := knn.ClassifyString("banana"); err != nil || category != "unknown" {
+		t.Errorf("expected fallback category; got %q (%v)", category, err)
+	}
+}
+
+func TestClassifyEmptyDocument(t *testing.T) {
+	knn := New(K(3))
+	knn.TrainString("apple banana cherry", "fruit")
+	knn.TrainString("carrot celery potato", "vegetable")
+
+	for _, doc := range []string{"", "the and of"} {
+		if _, err := knn.ClassifyString(doc); err != ErrNotClassified {
+			t.Errorf("expected ErrNotClassified for %q; got %v", doc, err)
+		}
+		if _, err := knn.NeighborsString(doc); err != ErrNotClassified {
+			t.Errorf("expected ErrNotClassified neighbors for %q; got %v", doc, err)
+		}
+	}
+
+	results := classifier.ClassifyBatch(context.Background(), knn, []classifier.Item{{ID: "1", Doc: strings.NewReader("the and of")}})
+	if len(results) != 1 || results[0].Err != ErrNotClassified {
+		t.Errorf("expected ErrNotClassified from a batch; got %+v", results)
+	}
+}
+
+func TestNGrams(t *testing.T) {
+	knn := New(K(1), Tokenizer(classifier.NewTokenizer(classifier.NGrams(2, 2))))
+	if err := knn.TrainString("refund", "billing"); err != ErrEmptyDocument {
+		t.Errorf("expected ErrEmptyDocument; got %v", err)
+	}
+	if len(knn.categories) != 0 || len(knn.documents) != 0 || len(knn.deleted) != 0 || knn.matrix.Size() != 0 {
+		t.Error("expected a rejected document to leave the classifier unchanged")
+	}
+
+	knn.TrainString("please refund my order", "billing")
+	knn.TrainString("my order never arrived", "shipping")
+	if category, err := knn.ClassifyString("refund my order please"); err != nil || category != "billing" {
+		t.Errorf("expected category billing; got %q (%v)", category, err)
+	}
+	if _, err := knn.ClassifyString("refund"); err != ErrNotClassified {
+		t.Errorf("expected ErrNotClassified for a single word; got %v", err)
+	}
+}
+
+func TestClassifyDoesNotGrowIndex(t *testing.T) {
+	knn := New()
+	knn.TrainString("apple banana cherry", "fruit")
+	knn.TrainString("carrot celery potato", "vegetable")
+
+	count := knn.index.Count()
+	for i := 0; i < 10; i++ {
+		if _, err := knn.ClassifyString(fmt.Sprintf("apple unseen%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if knn.index.Count() != count {
+		t.Errorf("expected classification to leave the index unchanged; got %d terms, expected %d", knn.index.Count(), count)
+	}
+}
+
+func TestConcurrentTrainAndClassify(t *testing.T) {
+	knn := New(K(3))
+	knn.TrainString("apple banana cherry", "fruit")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				knn.TrainString(fmt.Sprintf("carrot celery potato%d", i*50+j), "vegetable")
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := knn.ClassifyString(fmt.Sprintf("apple query%d", i*50+j)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if size := knn.matrix.Size(); size != 201 {
+		t.Errorf("expected 201 rows; got %v", size)
+	}
+	if _, ok := knn.index.Term(knn.index.Count()); ok || knn.index.IndexOf("query0") >= 0 {
+		t.Error("expected query terms to be excluded from the index")
+	}
+}
+
+func TestTokenizerError(t *testing.T) {
+	knn := New(Tokenizer(classifier.NewTokenizer(classifier.MaxTokenSize(16))))
+	long := "apple banana " + strings.Repeat("x", 32)
+
+	if err := knn.TrainString(long, "fruit"); err != bufio.ErrTooLong {
+		t.Errorf("expected bufio.ErrTooLong; got %v", err)
+	}
+	if size := knn.matrix.Size(); size != 0 {
+		t.Errorf("expected failed training to leave the matrix unchanged; got %v rows", size)
+	}
+
+	knn.TrainString("apple banana", "fruit")
+	if _, err := knn.ClassifyString(long); err != bufio.ErrTooLong {
+		t.Errorf("expected bufio.ErrTooLong; got %v", err)
+	}
+	if _, err := knn.NeighborsString(long); err != bufio.ErrTooLong {
+		t.Errorf("expected bufio.ErrTooLong; got %v", err)
+	}
+}
+
+func TestContext(t *testing.T) {
+	defer leaktest.Check(t)()
+	knn := New()
+	knn.TrainString("apple banana cherry", "fruit")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := knn.TrainContext(ctx, leaktest.Endless{}, "vegetable"); err != context.DeadlineExceeded {
+		t.Errorf("expected training to time out; got %v", err)
+	}
+	if size := knn.matrix.Size(); size != 1 {
+		t.Errorf("expected cancelled training to leave the matrix unchanged; got %v rows", size)
+	}
+
+	if _, err := knn.ClassifyContext(ctx, strings.NewReader("apple")); err != context.DeadlineExceeded {
+		t.Errorf("expected classification to time out; got %v", err)
+	}
+	if category, err := knn.ClassifyContext(context.Background(), strings.NewReader("apple")); err != nil || category != "fruit" {
+		t.Errorf("expected category fruit; got %q (%v)", category, err)
+	}
+
+}
+
+func TestTopResults(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	scores := make([]float64, 100)
+	results := make(topResults, 0, 5)
+	for i := range scores {
+		scores[i] = rnd.Float64()
+		results.offer(5, topResult{Row: i, Score: scores[i]})
+	}
+
+	sort.Float64s(scores)
+	sort.Sort(results)
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results; got %d", len(results))
+	}
+	for i, result := range results {
+		assertEquivalent(t, result.Score, scores[len(scores)-5+i], 0)
+	}
+}
+
+func BenchmarkClassify(b *testing.B) {
+	c := newCorpus(20000, 100)
+	classifiers := []struct {
+		Name       string
+		Classifier *Classifier
+	}{
+		{"Scan", New(K(10), WeightScheme(classifier.LogNorm), Similarity(EuclideanDistance))},
+		{"Candidates", New(K(10), WeightScheme(classifier.LogNorm), InvertedIndex())},
+		{"MaxScore", New(K(10), WeightScheme(classifier.LogNorm), MaxScore())},
+	}
+
+	for _, test := range classifiers {
+		c.train(test.Classifier)
+		b.Run(test.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				test.Classifier.ClassifyString(c.queries[i%len(c.queries)])
+			}
+		})
+	}
+}
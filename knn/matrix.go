@@ -37,19 +37,29 @@ func (m *sparse) Add(index *index.TermIndex, weight classifier.WeightScheme, doc
 	m.ptr = append(m.ptr, cur)
 }
 
-// MakeRow creates and returns a new sparseRow without adding it to the underlying matrix
+// MakeRow creates and returns a new sparseRow without adding it to the underlying
+// matrix. The index is only read: terms outside of the vocabulary are assigned
+// unique negative features, which never match a row of the matrix but still
+// contribute to the magnitude of the returned row. A document without any
+// terms returns an empty row.
 func (m *sparse) MakeRow(index *index.TermIndex, weight classifier.WeightSchemeStrategy, wordFreq map[string]float64) *sparseRow {
+	if len(wordFreq) == 0 {
+		return newSparseRow(0)
+	}
 	i := 0
+	oov := 0
 	var idx int
 	this := newSparseRow(len(wordFreq))
+	scheme := weight(wordFreq)
 
 	for term := range wordFreq {
 		idx = index.IndexOf(term)
 		if idx < 0 {
-			idx = index.Add(term)
+			oov--
+			idx = oov
 		}
 		this.ind[i] = idx
-		this.val[i] = weight(wordFreq)(term)
+		this.val[i] = scheme(term)
 		i++
 	}
 
@@ -57,28 +67,38 @@ func (m *sparse) MakeRow(index *index.TermIndex, weight classifier.WeightSchemeS
 	return this
 }
 
-// Rows returns an iterator over the matrix
+// Rows returns an iterator over the matrix. Each call returns a new sparseRow,
+// so rows may be retained or shared between goroutines.
 func (m *sparse) Rows() func() *sparseRow {
 	i := 0
-	r := &sparseRow{}
 
 	return func() *sparseRow {
 		if i == (len(m.ptr) - 1) {
 			return nil
 		}
 
-		start := m.ptr[i]
-		end := m.ptr[i+1]
-
-		r.index = i
-		r.ind = m.ind[start:end]
-		r.val = m.val[start:end]
+		r := m.Row(i)
 		i++
-
 		return r
 	}
 }
 
+// Row returns the row at index i
+func (m *sparse) Row(i int) *sparseRow {
+	r := &sparseRow{}
+	m.view(i, r)
+	return r
+}
+
+// view points r at the row at index i without copying or allocating
+func (m *sparse) view(i int, r *sparseRow) {
+	start := m.ptr[i]
+	end := m.ptr[i+1]
+	r.index = i
+	r.ind = m.ind[start:end]
+	r.val = m.val[start:end]
+}
+
 // Head returns the first 10 rows in the underlying matrix
 func (m *sparse) Head() []*sparseRow {
 	iterator := m.Rows()
@@ -156,6 +176,16 @@ func (r *sparseRow) Sum() float64 {
 	return sum
 }
 
+// negative reports whether any value of the row is negative
+func (r *sparseRow) negative() bool {
+	for _, val := range r.val {
+		if val < 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // Square the row
 func (r *sparseRow) Square() float64 {
 	sum := 0.0
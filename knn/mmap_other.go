@@ -0,0 +1,19 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package knn
+
+import (
+	"io"
+	"os"
+)
+
+// mmap reads the contents of f into memory on platforms without memory mapping
+func mmap(f *os.File) ([]byte, func() error, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error {
+		return nil
+	}, nil
+}
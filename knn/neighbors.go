@@ -0,0 +1,103 @@
+package knn
+
+import (
+	"context"
+	"io"
+	"math"
+	"sort"
+)
+
+// Neighbor provides a training document that is near to a classified document
+type Neighbor struct {
+	// Row is the index of the training document within the model
+	Row int
+	// ID is the ID of an identified training document
+	ID string
+	// Metadata is the metadata of an identified training document
+	Metadata map[string]string
+	// Category is the category of the training document
+	Category string
+	// Similarity is the similarity score between the documents
+	Similarity float64
+	// Terms lists the terms that are shared by both documents
+	Terms []Term
+}
+
+// Term provides a term shared by a classified document and one of its neighbors
+type Term struct {
+	Term string
+	// Weight is the weight of the term within the neighbor
+	Weight float64
+	// Query is the weight of the term within the classified document
+	Query float64
+}
+
+// Neighbors returns the k nearest training documents, ordered from the most to
+// the least similar, along with the terms that they share with the document.
+// Shared terms are ordered by the product of their weights.
+func (c *Classifier) Neighbors(r io.Reader) ([]Neighbor, error) {
+	wordFreq, err := c.termFrequencies(context.Background(), r)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	this, results, _ := c.nearest(context.Background(), wordFreq)
+	if len(results) == 0 {
+		return nil, ErrNotClassified
+	}
+
+	k := int(math.Min(float64(c.k), float64(len(results))))
+	neighbors := make([]Neighbor, k)
+	for i := range neighbors {
+		result := results[len(results)-1-i]
+		neighbors[i] = Neighbor{
+			Row:        result.Row,
+			ID:         c.documents[result.Row].ID,
+			Metadata:   c.documents[result.Row].Metadata,
+			Category:   result.Category,
+			Similarity: result.Score,
+			Terms:      c.sharedTerms(c.matrix.Row(result.Row), this),
+		}
+	}
+	return neighbors, nil
+}
+
+// NeighborsString provides convenience neighbor lookup for strings
+func (c *Classifier) NeighborsString(doc string) ([]Neighbor, error) {
+	return c.Neighbors(asReader(doc))
+}
+
+// sharedTerms merges the sorted features of both rows and resolves the
+// features that they have in common through the term index
+func (c *Classifier) sharedTerms(row, query *sparseRow) []Term {
+	terms := make([]Term, 0)
+	for i, j := 0, 0; i < row.Len() && j < query.Len(); {
+		switch {
+		case row.Feature(i) < query.Feature(j):
+			i++
+		case row.Feature(i) > query.Feature(j):
+			j++
+		default:
+			if term, ok := c.index.Term(row.Feature(i)); ok {
+				terms = append(terms, Term{
+					Term:   term,
+					Weight: row.val[i],
+					Query:  query.val[j],
+				})
+			}
+			i++
+			j++
+		}
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		a, b := terms[i].Weight*terms[i].Query, terms[j].Weight*terms[j].Query
+		if a != b {
+			return a > b
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	return terms
+}
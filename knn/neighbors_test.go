@@ -0,0 +1,85 @@
+package knn
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/n3integration/classifier"
+)
+
+func TestNeighbors(t *testing.T) {
+	knn := New(K(2))
+	if _, err := knn.NeighborsString("apple"); err != ErrNotClassified {
+		t.Errorf("expected untrained classifier to return ErrNotClassified; got %v", err)
+	}
+
+	knn.TrainString("apple banana cherry", "fruit")
+	knn.TrainString("carrot celery potato", "vegetable")
+	knn.TrainString("apple banana smoothie", "drink")
+
+	neighbors, err := knn.NeighborsString("banana apple pie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(neighbors) != 2 {
+		t.Fatalf("expected k neighbors; got %d", len(neighbors))
+	}
+
+	for _, neighbor := range neighbors {
+		if neighbor.Row != 0 && neighbor.Row != 2 {
+			t.Errorf("unexpected neighbor row %d", neighbor.Row)
+		}
+		if neighbor.Category != knn.categories[neighbor.Row] {
+			t.Errorf("expected category %s; got %s", knn.categories[neighbor.Row], neighbor.Category)
+		}
+		if len(neighbor.Terms) != 2 || neighbor.Terms[0].Term != "apple" || neighbor.Terms[1].Term != "banana" {
+			t.Errorf("expected apple and banana to be shared; got %v", neighbor.Terms)
+		}
+		for _, term := range neighbor.Terms {
+			if term.Weight != 1 || term.Query != 1 {
+				t.Errorf("expected binary weights; got %+v", term)
+			}
+		}
+	}
+	if neighbors[0].Similarity < neighbors[1].Similarity {
+		t.Error("expected neighbors in descending order of similarity")
+	}
+}
+
+func TestTrainDocument(t *testing.T) {
+	knn := New(K(1))
+	doc := classifier.Document{ID: "doc-1", Metadata: map[string]string{"source": "crm"}}
+	if err := knn.TrainDocument(doc, strings.NewReader("apple banana cherry"), "fruit"); err != nil {
+		t.Fatal(err)
+	}
+	if err := knn.TrainDocument(doc, strings.NewReader("apple"), "fruit"); err != classifier.ErrDuplicateDocument {
+		t.Errorf("expected duplicate document error; got %v", err)
+	}
+	if err := knn.TrainDocument(classifier.Document{}, strings.NewReader("apple"), "fruit"); err != classifier.ErrMissingDocumentID {
+		t.Errorf("expected missing document ID error; got %v", err)
+	}
+	knn.TrainString("carrot celery potato", "vegetable")
+
+	var buf bytes.Buffer
+	if err := knn.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := New(K(1))
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []*Classifier{knn, loaded} {
+		neighbors, err := c.NeighborsString("banana smoothie")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if neighbors[0].ID != "doc-1" || neighbors[0].Metadata["source"] != "crm" {
+			t.Errorf("expected neighbor to surface the document; got %+v", neighbors[0])
+		}
+		if c.rows["doc-1"] != 0 {
+			t.Errorf("expected document to be indexed by ID")
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package knn
+
+import (
+	"errors"
+	"sync"
+)
+
+// minRowsPerWorker prevents small matrices from being split across goroutines,
+// where the cost of coordination would exceed the cost of scoring
+const minRowsPerWorker = 1024
+
+// Workers scores rows on up to n goroutines when scanning the matrix or
+// retrieving candidates from the inverted index. Each worker scores a
+// contiguous range of rows and keeps its own k most similar results, which are
+// merged once every worker has finished. Similarity scores must be safe for
+// concurrent use, as the built-in scores are. MaxScore pruning and SimHash
+// search remain sequential.
+func Workers(n int) Option {
+	return func(c *Classifier) error {
+		if n < 1 {
+			return errors.New("the number of workers must be a positive integer")
+		}
+		c.workers = n
+		return nil
+	}
+}
+
+// partition splits the rows of the matrix into contiguous ranges, scores each
+// range on its own goroutine and merges the k most similar results. The caller
+// must hold c.mu.
+func (c *Classifier) partition(search func(low, high int) topResults) topResults {
+	rows := len(c.categories)
+	workers := c.workers
+	if max := rows / minRowsPerWorker; workers > max {
+		workers = max
+	}
+	if workers <= 1 {
+		return search(0, rows)
+	}
+
+	partial := make([]topResults, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			partial[w] = search(w*rows/workers, (w+1)*rows/workers)
+		}(w)
+	}
+	wg.Wait()
+
+	results := make(topResults, 0, c.k)
+	for _, p := range partial {
+		for _, result := range p {
+			results.offer(c.k, result)
+		}
+	}
+	return results
+}
@@ -0,0 +1,70 @@
+package knn
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/n3integration/classifier"
+)
+
+func TestWorkers(t *testing.T) {
+	if _, err := NewClassifier(Workers(0)); err == nil {
+		t.Error("expected an error for zero workers")
+	}
+
+	c := newCorpus(5000, 20)
+	for _, similarity := range []SimilarityScore{CosineSimilarity, EuclideanDistance} {
+		sequential := New(K(5), WeightScheme(classifier.LogNorm), Similarity(similarity))
+		parallel := New(K(5), WeightScheme(classifier.LogNorm), Similarity(similarity), Workers(4))
+		c.train(sequential)
+		c.train(parallel)
+		sequential.RemoveRow(1)
+		parallel.RemoveRow(1)
+
+		for _, query := range c.queries {
+			expected, err := sequential.NeighborsString(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := parallel.NeighborsString(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(actual) != len(expected) {
+				t.Fatalf("expected %d neighbors; got %d", len(expected), len(actual))
+			}
+			for i := range expected {
+				assertEquivalent(t, actual[i].Similarity, expected[i].Similarity, 1e-12)
+				if actual[i].Row == 1 {
+					t.Error("expected removed rows to be excluded")
+				}
+			}
+		}
+	}
+}
+
+func TestRowsAreNotShared(t *testing.T) {
+	knn := New()
+	knn.TrainString("apple banana", "fruit")
+	knn.TrainString("carrot", "vegetable")
+
+	next := knn.matrix.Rows()
+	first, second := next(), next()
+	if first == second || first.Index() != 0 || first.Len() != 2 {
+		t.Errorf("expected each row to be retained; got %v and %v", first, second)
+	}
+}
+
+func BenchmarkWorkers(b *testing.B) {
+	c := newCorpus(20000, 100)
+	for _, workers := range []int{1, 2, 4, 8} {
+		knn := New(K(10), WeightScheme(classifier.LogNorm), Similarity(EuclideanDistance), Workers(workers))
+		c.train(knn)
+
+		b.Run(fmt.Sprint(workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				knn.ClassifyString(c.queries[i%len(c.queries)])
+			}
+		})
+	}
+}
@@ -0,0 +1,400 @@
+package knn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"unsafe"
+
+	"github.com/n3integration/classifier"
+	"github.com/n3integration/classifier/index"
+	"github.com/n3integration/classifier/internal/funcname"
+)
+
+const (
+	// formatVersion is the current version of the persisted model format
+	formatVersion = 2
+	// alignment of the CSR arrays within the persisted model
+	alignment = 8
+)
+
+var (
+	magic = []byte("KNNM")
+
+	// ErrCorruptModel indicates that a persisted model could not be decoded
+	ErrCorruptModel = errors.New("corrupt k-nearest neighbor model")
+
+	// ErrWeightSchemeMismatch indicates that a persisted model was trained with
+	// a different term weight scheme than the one it is being loaded with
+	ErrWeightSchemeMismatch = errors.New("weight scheme does not match the persisted model")
+)
+
+// Save writes the trained model to w in a compact binary format, so that it can
+// be restored with Load or LoadFile without replaying the training data. Removed
+// rows are omitted, as if the model had been compacted.
+//
+// All integers are little endian. The model is written as:
+//
+//	magic        4 bytes "KNNM"
+//	version      uint32; currently 2
+//	tokenizer    uint32 length prefixed tokenizer fingerprint
+//	weights      uint32 length prefixed weight scheme name
+//	index        uint32 length prefixed term index (see index.TermIndex.MarshalBinary)
+//	rows         uint64 number of rows, followed by a uint32 length prefixed
+//	             category for each row
+//	documents    for each row, a uint32 length prefixed document ID followed by
+//	             a uint32 number of metadata entries, each written as a uint32
+//	             length prefixed key and value (since version 2)
+//	nnz          uint64 number of non-zero values
+//	padding      zero bytes up to the next 8 byte boundary
+//	ind          nnz int64 column indices
+//	val          nnz float64 values
+//	ptr          rows+1 int64 row offsets
+//
+// The CSR arrays are aligned to 8 bytes from the start of the model, which
+// allows LoadFile to memory map them without copying.
+func (c *Classifier) Save(w io.Writer) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	terms, err := c.index.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	categories, documents, matrix := c.compacted()
+	enc := &encoder{w: bufio.NewWriter(w)}
+	enc.bytes(magic)
+	enc.uint32(formatVersion)
+	enc.string(classifier.Fingerprint(c.tokenizer))
+	enc.string(funcname.Of(c.weightScheme))
+	enc.string(string(terms))
+	enc.uint64(uint64(len(categories)))
+	for _, category := range categories {
+		enc.string(category)
+	}
+	for _, doc := range documents {
+		enc.string(doc.ID)
+		keys := make([]string, 0, len(doc.Metadata))
+		for key := range doc.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		enc.uint32(uint32(len(keys)))
+		for _, key := range keys {
+			enc.string(key)
+			enc.string(doc.Metadata[key])
+		}
+	}
+	enc.uint64(uint64(len(matrix.ind)))
+	enc.pad(alignment)
+	for _, v := range matrix.ind {
+		enc.uint64(uint64(v))
+	}
+	for _, v := range matrix.val {
+		enc.uint64(math.Float64bits(v))
+	}
+	for _, v := range matrix.ptr {
+		enc.uint64(uint64(v))
+	}
+	return enc.flush()
+}
+
+// Load replaces the state of the classifier with a model previously written by
+// Save. If the model was saved with a tokenizer or weight scheme that does not
+// match the classifier's configuration, an error is returned and the classifier
+// is left unchanged.
+func (c *Classifier) Load(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return c.decode(data, nil)
+}
+
+// LoadFile restores a model previously written by Save from the named file. Where
+// the platform supports it, the file is memory mapped and the CSR arrays reference
+// the mapping directly instead of being copied onto the heap. The mapping is
+// released by Close or when another model is loaded. Loading reads the term
+// index, categories and documents onto the heap, which is proportional to the
+// vocabulary and the number of rows. The inverted index of the Exact searcher,
+// or the tables of a SimHash searcher, are not persisted; they are built on the
+// heap by the first classification, in time and memory proportional to the
+// number of stored term weights.
+func (c *Classifier) LoadFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, release, err := mmap(f)
+	if err != nil {
+		return err
+	}
+	if err := c.decode(data, release); err != nil {
+		release()
+		return err
+	}
+	return nil
+}
+
+// Close releases a memory mapped model loaded by LoadFile and resets the
+// classifier to an untrained state
+func (c *Classifier) Close() error {
+	c.mu.Lock()
+	release := c.release
+	c.release = nil
+	c.categories = make([]string, 0)
+	c.documents = make([]classifier.Document, 0)
+	c.rows = make(map[string]int)
+	c.deleted = make([]bool, 0)
+	c.tombstones = 0
+	c.index = index.NewTermIndex(defaultIndexCapacity)
+	c.matrix = newSparseMatrix()
+	c.search.reset(c.matrix)
+	c.mu.Unlock()
+
+	if release != nil {
+		return release()
+	}
+	return nil
+}
+
+// decode restores the model from data. When release is provided, data is
+// memory mapped and the CSR arrays reference it directly where the platform
+// allows; otherwise they are copied.
+func (c *Classifier) decode(data []byte, release func() error) error {
+	view := release != nil
+	dec := &decoder{data: data}
+	if !bytes.Equal(dec.bytes(len(magic)), magic) {
+		return ErrCorruptModel
+	}
+	version := dec.uint32()
+	if dec.err == nil && (version < 1 || version > formatVersion) {
+		return fmt.Errorf("unsupported model version: %d", version)
+	}
+
+	tokenizer := dec.string()
+	weights := dec.string()
+	terms := dec.string()
+	rows := dec.uint64()
+	if dec.err != nil || rows > uint64(len(data)) {
+		return ErrCorruptModel
+	}
+	categories := make([]string, rows)
+	for i := range categories {
+		categories[i] = dec.string()
+	}
+	documents := make([]classifier.Document, rows)
+	ids := make(map[string]int)
+	if version >= 2 {
+		for i := range documents {
+			documents[i].ID = dec.string()
+			if n := dec.uint32(); n > 0 && dec.err == nil {
+				documents[i].Metadata = make(map[string]string)
+				for j := 0; j < int(n) && dec.err == nil; j++ {
+					key := dec.string()
+					documents[i].Metadata[key] = dec.string()
+				}
+			}
+			if documents[i].ID != "" {
+				ids[documents[i].ID] = i
+			}
+		}
+	}
+	nnz := dec.uint64()
+	dec.align(alignment)
+	if dec.err != nil || nnz > uint64(len(data)) {
+		return ErrCorruptModel
+	}
+	ind := dec.ints(int(nnz), view)
+	val := dec.floats(int(nnz), view)
+	ptr := dec.ints(int(rows)+1, view)
+	if dec.err != nil {
+		return ErrCorruptModel
+	}
+	if ptr[0] != 0 || ptr[rows] != int(nnz) {
+		return ErrCorruptModel
+	}
+	for i := 1; i < len(ptr); i++ {
+		if ptr[i] < ptr[i-1] {
+			return ErrCorruptModel
+		}
+	}
+
+	idx := index.NewTermIndex(0)
+	if err := idx.UnmarshalBinary([]byte(terms)); err != nil {
+		return ErrCorruptModel
+	}
+	// features must reference the index and be sorted within each row, which
+	// binary searches of the row rely on
+	features := idx.Count()
+	for i := 0; i < int(rows); i++ {
+		for j := ptr[i]; j < ptr[i+1]; j++ {
+			if ind[j] < 0 || ind[j] >= features || (j > ptr[i] && ind[j] <= ind[j-1]) {
+				return ErrCorruptModel
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if tokenizer != "" && tokenizer != classifier.Fingerprint(c.tokenizer) {
+		return classifier.ErrTokenizerMismatch
+	}
+	if weights != funcname.Of(c.weightScheme) {
+		return ErrWeightSchemeMismatch
+	}
+
+	prev := c.release
+	c.index = idx
+	c.categories = categories
+	c.documents = documents
+	c.rows = ids
+	c.deleted = make([]bool, rows)
+	c.tombstones = 0
+	c.matrix = &sparse{ind: ind, val: val, ptr: ptr}
+	c.search.reset(c.matrix)
+	c.release = release
+
+	if prev != nil {
+		return prev()
+	}
+	return nil
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	n   int
+	err error
+	buf [8]byte
+}
+
+func (e *encoder) bytes(b []byte) {
+	if e.err != nil {
+		return
+	}
+	var n int
+	n, e.err = e.w.Write(b)
+	e.n += n
+}
+
+func (e *encoder) uint32(v uint32) {
+	binary.LittleEndian.PutUint32(e.buf[:4], v)
+	e.bytes(e.buf[:4])
+}
+
+func (e *encoder) uint64(v uint64) {
+	binary.LittleEndian.PutUint64(e.buf[:], v)
+	e.bytes(e.buf[:])
+}
+
+func (e *encoder) string(s string) {
+	e.uint32(uint32(len(s)))
+	e.bytes([]byte(s))
+}
+
+func (e *encoder) pad(n int) {
+	if r := e.n % n; r != 0 {
+		e.bytes(make([]byte, n-r))
+	}
+}
+
+func (e *encoder) flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+type decoder struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.data)-d.off < n {
+		d.err = ErrCorruptModel
+		return nil
+	}
+	b := d.data[d.off : d.off+n]
+	d.off += n
+	return b
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes(int(d.uint32())))
+}
+
+func (d *decoder) align(n int) {
+	if r := d.off % n; r != 0 {
+		d.bytes(n - r)
+	}
+}
+
+func (d *decoder) ints(n int, view bool) []int {
+	b := d.bytes(n * 8)
+	if d.err != nil {
+		return nil
+	}
+	if view && canView(b) && strconv.IntSize == 64 {
+		return unsafe.Slice((*int)(unsafe.Pointer(&b[0])), n)
+	}
+	values := make([]int, n)
+	for i := range values {
+		values[i] = int(binary.LittleEndian.Uint64(b[i*8:]))
+	}
+	return values
+}
+
+func (d *decoder) floats(n int, view bool) []float64 {
+	b := d.bytes(n * 8)
+	if d.err != nil {
+		return nil
+	}
+	if view && canView(b) {
+		return unsafe.Slice((*float64)(unsafe.Pointer(&b[0])), n)
+	}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[i*8:]))
+	}
+	return values
+}
+
+// canView reports whether b can be reinterpreted in place as 64 bit values
+func canView(b []byte) bool {
+	return len(b) > 0 && littleEndian && uintptr(unsafe.Pointer(&b[0]))%alignment == 0
+}
+
+var littleEndian = func() bool {
+	v := uint16(1)
+	return *(*byte)(unsafe.Pointer(&v)) == 1
+}()
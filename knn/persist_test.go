@@ -0,0 +1,139 @@
+package knn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/n3integration/classifier"
+)
+
+var headlines = []string{
+	`Small Businesses Keep Hiring as Fed Raises Rates to Cool Economy`,
+	`How Eagles can win 2023 Super Bowl: Jalen Hurts, dominant offensive line pave the way for championship run`,
+	`Stocks rally as investors weigh quarterly earnings`,
+}
+
+func newTestClassifier(opts ...Option) *Classifier {
+	return New(append([]Option{
+		K(4),
+		Similarity(EuclideanDistance),
+		WeightScheme(classifier.TermFrequency),
+		Tokenizer(classifier.NewTokenizer(
+			classifier.Filters(classifier.IsNotStopWord, classifier.IsWord),
+			classifier.SplitFunc(classifier.ScanAlphaWords),
+		)),
+	}, opts...)...)
+}
+
+func TestSaveLoad(t *testing.T) {
+	trained := newTestClassifier()
+	loadTestData(t, trained)
+
+	var buf bytes.Buffer
+	if err := trained.Save(&buf); err != nil {
+		t.Fatal("failed to save model:", err)
+	}
+
+	t.Run("Reader", func(t *testing.T) {
+		loaded := newTestClassifier()
+		if err := loaded.Load(bytes.NewReader(buf.Bytes())); err != nil {
+			t.Fatal("failed to load model:", err)
+		}
+		assertSameClassifications(t, trained, loaded)
+	})
+
+	t.Run("File", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "model.knn")
+		if err := os.WriteFile(name, buf.Bytes(), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded := newTestClassifier()
+		if err := loaded.LoadFile(name); err != nil {
+			t.Fatal("failed to load model:", err)
+		}
+		if loaded.search.(*exact).postings != nil {
+			t.Error("expected the default searcher to scan rather than build an inverted index")
+		}
+		assertSameClassifications(t, trained, loaded)
+
+		if err := loaded.TrainString("quarterly earnings beat expectations", "business"); err != nil {
+			t.Fatal("failed to train memory mapped model:", err)
+		}
+		if err := loaded.Close(); err != nil {
+			t.Fatal("failed to close model:", err)
+		}
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		if err := New().Load(bytes.NewReader(buf.Bytes())); err != classifier.ErrTokenizerMismatch {
+			t.Errorf("expected tokenizer mismatch; got %v", err)
+		}
+		if err := newTestClassifier(WeightScheme(classifier.Binary)).Load(bytes.NewReader(buf.Bytes())); err != ErrWeightSchemeMismatch {
+			t.Errorf("expected weight scheme mismatch; got %v", err)
+		}
+	})
+
+	t.Run("Corrupt", func(t *testing.T) {
+		if err := newTestClassifier().Load(bytes.NewReader(buf.Bytes()[:buf.Len()/2])); err != ErrCorruptModel {
+			t.Errorf("expected corrupt model; got %v", err)
+		}
+	})
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	trained := New(K(1))
+	trained.TrainString("apple banana", "fruit")
+	trained.TrainString("carrot", "vegetable")
+	var buf bytes.Buffer
+	if err := trained.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	model := buf.Bytes()
+
+	t.Run("Term Count", func(t *testing.T) {
+		// replace the term index with a count that exceeds the remaining data
+		offset := len(magic) + 4
+		for i := 0; i < 2; i++ {
+			offset += 4 + int(binary.LittleEndian.Uint32(model[offset:]))
+		}
+		size := int(binary.LittleEndian.Uint32(model[offset:]))
+		blob := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}
+
+		corrupt := append([]byte{}, model[:offset]...)
+		corrupt = binary.LittleEndian.AppendUint32(corrupt, uint32(len(blob)))
+		corrupt = append(corrupt, blob...)
+		corrupt = append(corrupt, model[offset+4+size:]...)
+		if err := New().Load(bytes.NewReader(corrupt)); err != ErrCorruptModel {
+			t.Errorf("expected corrupt model; got %v", err)
+		}
+	})
+
+	t.Run("Feature", func(t *testing.T) {
+		// ind precedes the nnz values and rows+1 offsets at the end of the model
+		nnz, rows := 3, 2
+		for _, feature := range []int64{-1, 3} {
+			corrupt := append([]byte{}, model...)
+			binary.LittleEndian.PutUint64(corrupt[len(corrupt)-8*(2*nnz+rows+1):], uint64(feature))
+			if err := New().Load(bytes.NewReader(corrupt)); err != ErrCorruptModel {
+				t.Errorf("expected corrupt model for feature %d; got %v", feature, err)
+			}
+		}
+	})
+}
+
+func assertSameClassifications(t *testing.T, expected, actual *Classifier) {
+	for _, headline := range headlines {
+		want, _ := expected.ClassifyString(headline)
+		got, err := actual.ClassifyString(headline)
+		if err != nil {
+			t.Fatalf("failed to classify %q: %v", headline, err)
+		}
+		if want != got {
+			t.Errorf("expected %q to be classified as %s; got %s", headline, want, got)
+		}
+	}
+}
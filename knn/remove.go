@@ -0,0 +1,146 @@
+package knn
+
+import (
+	"errors"
+
+	"github.com/n3integration/classifier"
+)
+
+// ErrUnknownDocument indicates that no live document exists with the provided ID or row
+var ErrUnknownDocument = errors.New("unknown document")
+
+// AutoCompact compacts the underlying matrix whenever the share of removed rows
+// exceeds ratio. Compaction renumbers the remaining rows.
+func AutoCompact(ratio float64) Option {
+	return func(c *Classifier) error {
+		if ratio <= 0 || ratio > 1 {
+			return errors.New("the compaction ratio must be between 0 and 1")
+		}
+		c.compactRatio = ratio
+		return nil
+	}
+}
+
+// Remove removes an identified document that was trained with TrainDocument
+func (c *Classifier) Remove(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	row, ok := c.rows[id]
+	if !ok {
+		return ErrUnknownDocument
+	}
+	return c.remove(row)
+}
+
+// RemoveRow removes the training document at row. Removed rows are excluded from
+// classification immediately, but remain in the underlying matrix until Compact
+// is called.
+func (c *Classifier) RemoveRow(row int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.live(row) {
+		return ErrUnknownDocument
+	}
+	return c.remove(row)
+}
+
+// Relabel changes the category of an identified document
+func (c *Classifier) Relabel(id string, category string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	row, ok := c.rows[id]
+	if !ok {
+		return ErrUnknownDocument
+	}
+	c.categories[row] = category
+	return nil
+}
+
+// RelabelRow changes the category of the training document at row
+func (c *Classifier) RelabelRow(row int, category string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.live(row) {
+		return ErrUnknownDocument
+	}
+	c.categories[row] = category
+	return nil
+}
+
+// Compact rewrites the underlying matrix without the rows that have been removed.
+// The remaining rows are renumbered in their original order, so row indices
+// returned prior to compaction are no longer valid; document IDs are unaffected.
+func (c *Classifier) Compact() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.compact()
+}
+
+func (c *Classifier) live(row int) bool {
+	return row >= 0 && row < len(c.deleted) && !c.deleted[row]
+}
+
+func (c *Classifier) remove(row int) error {
+	r := c.matrix.Row(row)
+	for i := 0; i < r.Len(); i++ {
+		if term, ok := c.index.Term(r.Feature(i)); ok {
+			c.index.Remove(term)
+		}
+	}
+
+	c.deleted[row] = true
+	c.tombstones++
+	delete(c.rows, c.documents[row].ID)
+
+	if c.compactRatio > 0 && float64(c.tombstones)/float64(len(c.deleted)) > c.compactRatio {
+		c.compact()
+	}
+	return nil
+}
+
+func (c *Classifier) compact() {
+	if c.tombstones == 0 {
+		return
+	}
+	c.categories, c.documents, c.matrix = c.compacted()
+	c.search.reset(c.matrix)
+	c.deleted = make([]bool, len(c.categories))
+	c.tombstones = 0
+	c.rows = make(map[string]int)
+	for row, doc := range c.documents {
+		if doc.ID != "" {
+			c.rows[doc.ID] = row
+		}
+	}
+}
+
+// compacted returns copies of the categories, documents, and matrix without the
+// removed rows. The existing arrays are never modified, since they may be memory
+// mapped.
+func (c *Classifier) compacted() ([]string, []classifier.Document, *sparse) {
+	if c.tombstones == 0 {
+		return c.categories, c.documents, c.matrix
+	}
+
+	live := len(c.categories) - c.tombstones
+	categories := make([]string, 0, live)
+	documents := make([]classifier.Document, 0, live)
+	matrix := newSparseMatrix()
+
+	for row, deleted := range c.deleted {
+		if deleted {
+			continue
+		}
+		r := c.matrix.Row(row)
+		categories = append(categories, c.categories[row])
+		documents = append(documents, c.documents[row])
+		matrix.ind = append(matrix.ind, r.ind...)
+		matrix.val = append(matrix.val, r.val...)
+		matrix.ptr = append(matrix.ptr, len(matrix.ind))
+	}
+	return categories, documents, matrix
+}
@@ -0,0 +1,109 @@
+package knn
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/n3integration/classifier"
+)
+
+func trainDocuments(t *testing.T, knn *Classifier) {
+	docs := []struct {
+		ID       string
+		Text     string
+		Category string
+	}{
+		{"1", "apple banana cherry", "fruit"},
+		{"2", "apple banana smoothie", "drink"},
+		{"3", "carrot celery potato", "vegetable"},
+	}
+	for _, doc := range docs {
+		if err := knn.TrainDocument(classifier.Document{ID: doc.ID}, strings.NewReader(doc.Text), doc.Category); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	knn := New()
+	trainDocuments(t, knn)
+
+	if category, _ := knn.ClassifyString("apple banana cherry"); category != "fruit" {
+		t.Fatalf("expected fruit; got %s", category)
+	}
+	if err := knn.Remove("1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := knn.Remove("1"); err != ErrUnknownDocument {
+		t.Errorf("expected ErrUnknownDocument; got %v", err)
+	}
+	if err := knn.RemoveRow(0); err != ErrUnknownDocument {
+		t.Errorf("expected ErrUnknownDocument; got %v", err)
+	}
+	if category, _ := knn.ClassifyString("apple banana cherry"); category != "drink" {
+		t.Errorf("expected removed row to be excluded; got %s", category)
+	}
+	if freq := knn.index.Frequency("cherry"); freq != 0 {
+		t.Errorf("expected term frequency to be decremented; got %v", freq)
+	}
+
+	var buf bytes.Buffer
+	if err := knn.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	knn.Compact()
+	if knn.matrix.Size() != 2 || len(knn.categories) != 2 || knn.tombstones != 0 {
+		t.Fatalf("expected compacted matrix with 2 rows; got %v", knn.matrix.Shape())
+	}
+	if knn.rows["2"] != 0 || knn.rows["3"] != 1 {
+		t.Errorf("expected rows to be renumbered; got %v", knn.rows)
+	}
+	if category, _ := knn.ClassifyString("carrot"); category != "vegetable" {
+		t.Errorf("expected vegetable; got %s", category)
+	}
+
+	loaded := New()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.matrix.Size() != 2 || loaded.rows["3"] != 1 {
+		t.Errorf("expected removed rows to be omitted from the saved model")
+	}
+}
+
+func TestRelabel(t *testing.T) {
+	knn := New()
+	trainDocuments(t, knn)
+
+	if err := knn.Relabel("3", "root"); err != nil {
+		t.Fatal(err)
+	}
+	if err := knn.RelabelRow(0, "berry"); err != nil {
+		t.Fatal(err)
+	}
+	if err := knn.Relabel("4", "root"); err != ErrUnknownDocument {
+		t.Errorf("expected ErrUnknownDocument; got %v", err)
+	}
+	if category, _ := knn.ClassifyString("carrot"); category != "root" {
+		t.Errorf("expected relabeled category; got %s", category)
+	}
+	if category, _ := knn.ClassifyString("cherry"); category != "berry" {
+		t.Errorf("expected relabeled category; got %s", category)
+	}
+}
+
+func TestAutoCompact(t *testing.T) {
+	knn := New(AutoCompact(0.5))
+	trainDocuments(t, knn)
+
+	knn.Remove("1")
+	if knn.tombstones != 1 {
+		t.Errorf("expected a tombstone below the compaction ratio")
+	}
+	knn.Remove("3")
+	if knn.tombstones != 0 || knn.matrix.Size() != 1 || knn.rows["2"] != 0 {
+		t.Errorf("expected the matrix to be compacted; got %v", knn.matrix.Shape())
+	}
+}
@@ -0,0 +1,212 @@
+package knn
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// simHashSeed seeds the random hyperplanes of SimHash searchers
+const simHashSeed = 0x5851f42d4c957f2d
+
+// Searcher provides a pluggable strategy for retrieving the nearest neighbors
+// of a document from the rows of the model. Use Exact or SimHash to create a
+// Searcher.
+type Searcher interface {
+	// validate checks the configuration of the searcher
+	validate() error
+	// reset discards any search structures, which are rebuilt from the rows of
+	// the matrix when they are next needed, so that loading a model does not
+	// pay for them up front
+	reset(m *sparse)
+	// add indexes a newly trained row; the caller must hold c.mu for writing
+	add(row *sparseRow)
+	// search returns the k most similar of the candidate rows for the document,
+	// stopping early if the context is cancelled; the caller must hold c.mu
+	search(ctx context.Context, c *Classifier, this *sparseRow) topResults
+}
+
+// Exact returns a Searcher that scores every row which could be a nearest
+// neighbor. Every row is scanned unless InvertedIndex is enabled, in which case
+// rows that share a term with the document are retrieved from an inverted
+// index for CosineSimilarity, and any remaining neighbors are taken from the
+// rows that share no terms, which all score zero. This is the default Searcher.
+func Exact() Searcher {
+	return &exact{postings: &postings{}}
+}
+
+type exact struct {
+	// mu guards the lazy construction of the postings by concurrent searches
+	mu       sync.Mutex
+	matrix   *sparse
+	postings *postings
+}
+
+func (s *exact) validate() error {
+	return nil
+}
+
+func (s *exact) reset(m *sparse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.matrix = m
+	s.postings = nil
+}
+
+func (s *exact) add(row *sparseRow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.postings != nil {
+		s.postings.add(row)
+	}
+}
+
+// inverted returns the inverted index of the matrix, building it on first use
+func (s *exact) inverted() *postings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.postings == nil {
+		s.postings = newPostings(s.matrix)
+	}
+	return s.postings
+}
+
+func (s *exact) search(ctx context.Context, c *Classifier, this *sparseRow) topResults {
+	if !c.inverted || !overlapOnly(c.similarity) || this.negative() {
+		return c.scan(ctx, this)
+	}
+
+	p := s.inverted()
+	switch {
+	case p.negative:
+		return c.scan(ctx, this)
+	case c.pruning:
+		return c.fill(this, c.maxScore(ctx, p, this))
+	default:
+		return c.fill(this, c.candidates(ctx, p, this))
+	}
+}
+
+// SimHash returns an approximate Searcher based on locality sensitive hashing
+// with random hyperplanes, which approximates cosine similarity. Each row is
+// hashed into bands signatures of bits bits; only rows that share at least one
+// band signature with the document are scored. More bands increase recall,
+// while more bits per band increase precision and reduce the number of scored
+// rows. Documents without any similar rows may have fewer than k neighbors.
+func SimHash(bands, bits int) Searcher {
+	return &simHash{bands: bands, bits: bits}
+}
+
+type simHash struct {
+	bands int
+	bits  int
+	// mu guards the lazy construction of the tables by concurrent searches
+	mu     sync.Mutex
+	matrix *sparse
+	tables []map[uint64][]int
+}
+
+func (s *simHash) validate() error {
+	if s.bands < 1 || s.bits < 1 || s.bits > 64 {
+		return errors.New("simhash requires at least one band of between 1 and 64 bits")
+	}
+	return nil
+}
+
+func (s *simHash) reset(m *sparse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.matrix = m
+	s.tables = nil
+}
+
+func (s *simHash) add(row *sparseRow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tables != nil {
+		s.insert(row)
+	}
+}
+
+func (s *simHash) insert(row *sparseRow) {
+	for band, signature := range s.signatures(row) {
+		s.tables[band][signature] = append(s.tables[band][signature], row.Index())
+	}
+}
+
+// hashed returns the band tables of the matrix, building them on first use
+func (s *simHash) hashed() []map[uint64][]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tables == nil {
+		s.tables = make([]map[uint64][]int, s.bands)
+		for i := range s.tables {
+			s.tables[i] = make(map[uint64][]int)
+		}
+		next := s.matrix.Rows()
+		for row := next(); row != nil; row = next() {
+			s.insert(row)
+		}
+	}
+	return s.tables
+}
+
+func (s *simHash) search(ctx context.Context, c *Classifier, this *sparseRow) topResults {
+	score := c.scorer(this)
+	seen := newRowSet(len(c.categories))
+	results := make(topResults, 0, c.k)
+
+	tables := s.hashed()
+	for band, signature := range s.signatures(this) {
+		if ctx.Err() != nil {
+			break
+		}
+		for _, row := range tables[band][signature] {
+			if !seen.visit(row) || c.deleted[row] {
+				continue
+			}
+			results.offer(c.k, score(row))
+		}
+	}
+	return results
+}
+
+// signatures projects the row onto bands*bits random hyperplanes and returns
+// the sign bits of each band. Hyperplane components are derived from a hash of
+// the feature, so they need not be stored.
+func (s *simHash) signatures(row *sparseRow) []uint64 {
+	planes := s.bands * s.bits
+	projections := make([]float64, planes)
+
+	for i := 0; i < row.Len(); i++ {
+		feature, val := row.Column(i)
+		for block := 0; block*64 < planes; block++ {
+			h := splitmix64((uint64(feature)*uint64(planes/64+1) + uint64(block)) ^ simHashSeed)
+			for bit := 0; bit < 64 && block*64+bit < planes; bit++ {
+				if h&(1<<bit) != 0 {
+					projections[block*64+bit] += val
+				} else {
+					projections[block*64+bit] -= val
+				}
+			}
+		}
+	}
+
+	signatures := make([]uint64, s.bands)
+	for band := range signatures {
+		for bit := 0; bit < s.bits; bit++ {
+			if projections[band*s.bits+bit] >= 0 {
+				signatures[band] |= 1 << bit
+			}
+		}
+	}
+	return signatures
+}
+
+// splitmix64 provides a fast, well distributed 64 bit hash
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
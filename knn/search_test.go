@@ -0,0 +1,128 @@
+package knn
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/n3integration/classifier"
+)
+
+// corpus generates random documents over a vocabulary of 4,096 terms, along
+// with near duplicate queries of randomly selected documents
+type corpus struct {
+	docs    []string
+	queries []string
+}
+
+func newCorpus(size, queries int) *corpus {
+	rnd := rand.New(rand.NewSource(1))
+	word := func() string {
+		return fmt.Sprintf("term%03x", rnd.Intn(4096))
+	}
+
+	c := &corpus{}
+	for i := 0; i < size; i++ {
+		words := make([]string, 20+rnd.Intn(30))
+		for j := range words {
+			words[j] = word()
+		}
+		c.docs = append(c.docs, strings.Join(words, " "))
+	}
+	for i := 0; i < queries; i++ {
+		words := strings.Fields(c.docs[rnd.Intn(size)])
+		for j := 0; j < len(words)/5; j++ {
+			words[rnd.Intn(len(words))] = word()
+		}
+		c.queries = append(c.queries, strings.Join(words, " "))
+	}
+	return c
+}
+
+func (c *corpus) train(knn *Classifier) {
+	for i, doc := range c.docs {
+		knn.TrainString(doc, fmt.Sprint(i%10))
+	}
+}
+
+// recall returns the share of the exact k nearest neighbors that are found by
+// the classifier's searcher, averaged over the queries
+func (c *corpus) recall(tb testing.TB, knn *Classifier, k int) float64 {
+	exhaustive := Exact()
+	exhaustive.reset(knn.matrix)
+
+	found := 0.0
+	for _, query := range c.queries {
+		this := queryRow(tb, knn, query)
+		expected := topRows(exhaustive.search(context.Background(), knn, this), k)
+		actual := topRows(knn.search.search(context.Background(), knn, this), k)
+		for row := range expected {
+			if _, ok := actual[row]; ok {
+				found++
+			}
+		}
+	}
+	return found / float64(k*len(c.queries))
+}
+
+func topRows(results topResults, k int) map[int]struct{} {
+	sort.Sort(results)
+	rows := make(map[int]struct{})
+	for i := 1; i <= k && i <= len(results); i++ {
+		rows[results[len(results)-i].Row] = struct{}{}
+	}
+	return rows
+}
+
+func TestSimHash(t *testing.T) {
+	c := newCorpus(2000, 50)
+	knn := New(K(1), WeightScheme(classifier.LogNorm), Search(SimHash(16, 8)))
+	c.train(knn)
+
+	if recall := c.recall(t, knn, 1); recall < 0.9 {
+		t.Errorf("expected recall@1 of near duplicates of at least 0.9; got %.2f", recall)
+	}
+
+	knn.RemoveRow(0)
+	knn.Compact()
+	if recall := c.recall(t, knn, 1); recall < 0.9 {
+		t.Errorf("expected recall@1 after compaction of at least 0.9; got %.2f", recall)
+	}
+
+	if _, err := NewClassifier(Search(SimHash(0, 8))); err == nil {
+		t.Error("expected an error for a searcher without bands")
+	}
+	if _, err := NewClassifier(Search(SimHash(4, 65))); err == nil {
+		t.Error("expected an error for more than 64 bits per band")
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	const k = 10
+	c := newCorpus(20000, 100)
+	searchers := []struct {
+		Name     string
+		Searcher func() Searcher
+	}{
+		{"Exact", Exact},
+		{"SimHash-8x16", func() Searcher { return SimHash(8, 16) }},
+		{"SimHash-16x8", func() Searcher { return SimHash(16, 8) }},
+		{"SimHash-32x12", func() Searcher { return SimHash(32, 12) }},
+	}
+
+	for _, s := range searchers {
+		knn := New(K(k), WeightScheme(classifier.LogNorm), Search(s.Searcher()))
+		c.train(knn)
+
+		b.Run(s.Name, func(b *testing.B) {
+			b.ReportMetric(c.recall(b, knn, k), "recall@10")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				knn.ClassifyString(c.queries[i%len(c.queries)])
+			}
+		})
+	}
+}
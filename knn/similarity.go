@@ -9,28 +9,24 @@ type SimilarityScore func(left, right *sparseRow) float64
 
 // EuclideanDistance between rows
 func EuclideanDistance(left, right *sparseRow) float64 {
-	distanceTo := func(left, right *sparseRow) float64 {
-		score := 0.0
-		terms := make(map[int]float64)
-		for i := 0; i < left.Len(); i++ {
-			term, val := left.Column(i)
-			terms[term] = val
-			score += math.Pow(val-right.Value(term), 2)
+	// features of both rows are sorted, so they are merged without allocating
+	score := 0.0
+	i, j := 0, 0
+	for i < left.Len() || j < right.Len() {
+		switch {
+		case j == right.Len() || (i < left.Len() && left.Feature(i) < right.Feature(j)):
+			score += math.Pow(left.val[i], 2)
+			i++
+		case i == left.Len() || right.Feature(j) < left.Feature(i):
+			score += math.Pow(right.val[j], 2)
+			j++
+		default:
+			score += math.Pow(left.val[i]-right.val[j], 2)
+			i++
+			j++
 		}
-
-		for i := 0; i < right.Len(); i++ {
-			term, _ := right.Column(i)
-			if _, ok := terms[term]; !ok {
-				score += math.Pow(0-right.Value(term), 2)
-			}
-		}
-		return 1 / (1 + math.Sqrt(score))
-	}
-
-	if left.Len() >= right.Len() {
-		return distanceTo(left, right)
 	}
-	return distanceTo(right, left)
+	return 1 / (1 + math.Sqrt(score))
 }
 
 // CosineSimilarity between rows
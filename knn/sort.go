@@ -8,7 +8,7 @@ type Partitioning interface {
 
 func search(values []int, v int) int {
 	low := 0
-	high := len(values)
+	high := len(values) - 1
 	for low <= high {
 		mid := (low + high) / 2
 		if v == values[mid] {
@@ -23,6 +23,9 @@ func search(values []int, v int) int {
 }
 
 func quickSort(m Partitioning, low int, high int) {
+	if low >= high {
+		return
+	}
 	stack := make(Stack, 0)
 
 	stack.push(low)
@@ -0,0 +1,108 @@
+package knn
+
+import (
+	"math"
+	"sort"
+
+	"github.com/n3integration/classifier"
+	"github.com/n3integration/classifier/internal/funcname"
+)
+
+// inverseDistanceEpsilon prevents division by zero for identical documents
+const inverseDistanceEpsilon = 1e-9
+
+// VotingStrategy provides pluggable support for weighting the vote of each of
+// the k nearest neighbors, given its zero based rank and its similarity to the
+// classified document
+type VotingStrategy func(rank int, similarity float64) float64
+
+// MajorityVoting counts each neighbor as a single vote
+func MajorityVoting(int, float64) float64 {
+	return 1
+}
+
+// SimilarityWeighted weights each vote by the similarity of the neighbor;
+// neighbors with a negative similarity do not vote
+func SimilarityWeighted(_ int, similarity float64) float64 {
+	return math.Max(0, similarity)
+}
+
+// InverseDistanceWeighted weights each vote by the inverse of the distance to the
+// neighbor, so that near duplicates dominate the vote. When configured with
+// Voting, the distance is derived from the similarity score of the classifier:
+// the euclidean distance for EuclideanDistance, and one minus the similarity for
+// CosineSimilarity, PearsonCorrelation, and custom scores. Called directly, the
+// similarity is assumed to be cosine.
+func InverseDistanceWeighted(_ int, similarity float64) float64 {
+	return inverseDistance(1 - similarity)
+}
+
+func inverseDistance(distance float64) float64 {
+	return 1 / (math.Max(0, distance) + inverseDistanceEpsilon)
+}
+
+// distance converts a score of the similarity s back into a distance
+func distance(s SimilarityScore, similarity float64) float64 {
+	if funcname.Of(s) == funcname.Of(EuclideanDistance) {
+		return 1/similarity - 1
+	}
+	return 1 - similarity
+}
+
+// RankWeighted weights each vote by the reciprocal of the rank of the neighbor,
+// such that the nearest neighbor counts for 1, the next for 1/2, and so on
+func RankWeighted(rank int, _ float64) float64 {
+	return 1 / float64(rank+1)
+}
+
+// strategy returns the voting strategy of the classifier, deriving the distance
+// of InverseDistanceWeighted from the configured similarity score
+func (c *Classifier) strategy() VotingStrategy {
+	if funcname.Of(c.voting) != funcname.Of(InverseDistanceWeighted) {
+		return c.voting
+	}
+	similarity := c.similarity
+	return func(_ int, score float64) float64 {
+		return inverseDistance(distance(similarity, score))
+	}
+}
+
+// vote tallies the weighted votes of the k most similar results. Scores are
+// the share of the total vote within each category, ordered from highest to
+// lowest. Ties are broken in favor of the category with the greater combined
+// similarity and then by category name.
+func (r topResults) vote(k int, strategy VotingStrategy) []classifier.Score {
+	k = int(math.Min(float64(k), float64(len(r))))
+	votes := make(map[string]float64)
+	similarity := make(map[string]float64)
+	total := 0.0
+
+	for rank := 0; rank < k; rank++ {
+		result := r[len(r)-1-rank]
+		weight := strategy(rank, result.Score)
+		votes[result.Category] += weight
+		similarity[result.Category] += result.Score
+		total += weight
+	}
+
+	scores := make([]classifier.Score, 0, len(votes))
+	for category, weight := range votes {
+		score := 0.0
+		if total > 0 {
+			score = weight / total
+		}
+		scores = append(scores, classifier.Score{Category: category, Score: score})
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		a, b := scores[i], scores[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if similarity[a.Category] != similarity[b.Category] {
+			return similarity[a.Category] > similarity[b.Category]
+		}
+		return a.Category < b.Category
+	})
+	return scores
+}
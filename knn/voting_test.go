@@ -0,0 +1,84 @@
+package knn
+
+import (
+	"testing"
+)
+
+// neighbors in ascending order of similarity, as returned by nearest
+var neighbors = topResults{
+	{Row: 3, Score: 0.2, Category: "b"},
+	{Row: 2, Score: 0.3, Category: "b"},
+	{Row: 1, Score: 0.5, Category: "c"},
+	{Row: 0, Score: 0.99, Category: "a"},
+}
+
+func TestVoting(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Strategy VotingStrategy
+		Expected string
+		Score    float64
+	}{
+		{"Majority", MajorityVoting, "b", 0.5},
+		{"Similarity Weighted", SimilarityWeighted, "a", 0.99 / 1.99},
+		{"Inverse Distance Weighted", InverseDistanceWeighted, "a", 100 / (100 + 2 + 1/0.7 + 1/0.8)},
+		{"Rank Weighted", RankWeighted, "a", 1 / (1 + 1.0/2 + 1.0/3 + 1.0/4)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			scores := neighbors.vote(4, test.Strategy)
+			if scores[0].Category != test.Expected {
+				t.Fatalf("expected %s; got %v", test.Expected, scores)
+			}
+			assertEquivalent(t, scores[0].Score, test.Score, 0.01)
+		})
+	}
+}
+
+func TestVotingTies(t *testing.T) {
+	// a and c tie with a single vote each; a has the greater similarity
+	scores := neighbors[1:].vote(2, MajorityVoting)
+	if scores[0].Category != "a" || scores[1].Category != "c" {
+		t.Errorf("expected tie to favor the more similar category; got %v", scores)
+	}
+
+	tied := topResults{
+		{Row: 1, Score: 0.5, Category: "y"},
+		{Row: 0, Score: 0.5, Category: "x"},
+	}
+	for i := 0; i < 10; i++ {
+		if scores := tied.vote(2, MajorityVoting); scores[0].Category != "x" {
+			t.Fatalf("expected ties to be broken by category name; got %v", scores)
+		}
+	}
+
+	knn := New(K(3), Voting(SimilarityWeighted))
+	knn.TrainString("apple banana cherry", "fruit")
+	knn.TrainString("apple tart", "dessert")
+	knn.TrainString("apple crumble", "dessert")
+	if category, _ := knn.ClassifyString("apple banana cherry"); category != "fruit" {
+		t.Errorf("expected the near duplicate to outweigh the majority; got %s", category)
+	}
+}
+
+func TestInverseDistanceSimilarity(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Similarity SimilarityScore
+		Score      float64
+		Weight     float64
+	}{
+		// a euclidean score of 0.2 is a distance of 4
+		{"Euclidean", EuclideanDistance, 0.2, 1.0 / 4},
+		{"Cosine", CosineSimilarity, 0.8, 1 / 0.2},
+		{"Pearson", PearsonCorrelation, -0.5, 1 / 1.5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			knn := New(Similarity(test.Similarity), Voting(InverseDistanceWeighted))
+			assertEquivalent(t, knn.strategy()(0, test.Score), test.Weight, 1e-6)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package naive
+
+import "sort"
+
+// example provides the bookkeeping of an identified training document
+type example struct {
+	Category string            `json:"category"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Features map[string]int    `json:"features"`
+}
+
+func (c *Classifier) addExample(id string, e *example) {
+	c.docs[id] = e
+	for feature := range e.Features {
+		if _, ok := c.feat2ids[feature]; !ok {
+			c.feat2ids[feature] = make(map[string]struct{})
+		}
+		c.feat2ids[feature][id] = struct{}{}
+	}
+}
+
+// documentIDs returns the sorted IDs of the identified training documents of
+// category that contain feature, limited to max entries
+func (c *Classifier) documentIDs(feature string, category string, max int) []string {
+	var ids []string
+	for id := range c.feat2ids[feature] {
+		if c.docs[id].Category == category {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > max {
+		ids = ids[:max]
+	}
+	return ids
+}
@@ -0,0 +1,64 @@
+package naive
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/n3integration/classifier"
+)
+
+func TestTrainDocument(t *testing.T) {
+	c := New(Multinomial(1))
+	docs := []struct {
+		ID       string
+		Text     string
+		Category string
+	}{
+		{"1", "earn cash prize online", "spam"},
+		{"2", "claim cash reward", "spam"},
+		{"3", "meeting agenda online", "ham"},
+	}
+	for _, doc := range docs {
+		meta := map[string]string{"source": "mail"}
+		if err := c.TrainDocument(classifier.Document{ID: doc.ID, Metadata: meta}, strings.NewReader(doc.Text), doc.Category); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := c.TrainDocument(classifier.Document{ID: "1"}, strings.NewReader("cash"), "spam"); err != classifier.ErrDuplicateDocument {
+		t.Errorf("expected duplicate document error; got %v", err)
+	}
+	if err := c.TrainDocument(classifier.Document{}, strings.NewReader("cash"), "spam"); err != classifier.ErrMissingDocumentID {
+		t.Errorf("expected missing document ID error; got %v", err)
+	}
+	assertFeatureCount(t, c, "cash", "spam", 2)
+	assertCategoryCount(t, c, "spam", 2)
+
+	var buf bytes.Buffer
+	if err := c.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := New()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []*Classifier{c, loaded} {
+		explanations, err := c.ExplainString("cash meeting")
+		if err != nil {
+			t.Fatal(err)
+		}
+		spam := explanations[0]
+		if spam.Category != "spam" || len(spam.Supporting) == 0 || spam.Supporting[0].Feature != "cash" {
+			t.Fatalf("expected cash to support spam; got %+v", spam)
+		}
+		if !reflect.DeepEqual(spam.Supporting[0].Documents, []string{"1", "2"}) {
+			t.Errorf("expected documents 1 and 2; got %v", spam.Supporting[0].Documents)
+		}
+		if c.docs["3"].Metadata["source"] != "mail" {
+			t.Error("expected document metadata to be retained")
+		}
+	}
+}
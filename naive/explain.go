@@ -0,0 +1,165 @@
+package naive
+
+import (
+	"context"
+	"io"
+	"sort"
+)
+
+// maxContributions limits the number of supporting and opposing features
+// returned for each category
+const maxContributions = 10
+
+// Contribution provides the log likelihood contribution of a single feature
+type Contribution struct {
+	// Feature is the feature as produced by the tokenizer
+	Feature string
+	// Count is the number of occurrences of the feature within the document,
+	// which is zero for the absent vocabulary terms of a bernoulli model
+	Count int
+	// Weight is the contribution of the feature to the log score of the category
+	Weight float64
+	// Relative is the difference between Weight and the mean contribution of
+	// the feature across every category
+	Relative float64
+	// Documents lists the IDs of identified training documents within the
+	// category that contain the feature
+	Documents []string
+}
+
+// Explanation describes how a document was scored against a single category
+type Explanation struct {
+	Category string
+	// Probability is the normalized posterior probability of the category
+	Probability float64
+	// LogScore is the unnormalized log posterior of the category
+	LogScore float64
+	// Supporting lists the features that most favor the category relative to
+	// the other categories, ordered from the strongest
+	Supporting []Contribution
+	// Opposing lists the features that most penalize the category relative to
+	// the other categories, ordered from the strongest
+	Opposing []Contribution
+}
+
+// Explain scores the document against every category and returns the features
+// that contributed most to, and most penalized, each category. Explanations are
+// ordered from the most to the least probable category. Document independent
+// terms, such as the prior, are reflected in LogScore only. For a bernoulli
+// model, each vocabulary term that is absent from the document contributes its
+// penalty of log(1-p) as a feature with a Count of zero.
+func (c *Classifier) Explain(r io.Reader) ([]Explanation, error) {
+	doc, err := c.features(context.Background(), r)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	categories := c.categories()
+	if len(categories) == 0 {
+		return nil, ErrNotClassified
+	}
+
+	explanations := make([]Explanation, len(categories))
+	contributions := make([][]Contribution, len(categories))
+	scores := make([]float64, len(categories))
+	mean := make(map[string]float64, len(doc))
+	absence, penalized := c.model.(absenceModel)
+
+	for i, category := range categories {
+		bias, weight := c.model.score(c, category)
+		if c.model.priors() {
+			bias += c.logPrior(category)
+		}
+		scores[i] = bias
+		for feature, count := range doc {
+			w := weight(feature, count)
+			scores[i] += w
+			mean[feature] += w / float64(len(categories))
+			contributions[i] = append(contributions[i], Contribution{
+				Feature: feature,
+				Count:   count,
+				Weight:  w,
+			})
+		}
+		if penalized {
+			// absent terms are already part of the bias
+			penalty := absence.absent(c, category)
+			for feature := range c.feat2doc {
+				if _, ok := doc[feature]; ok {
+					continue
+				}
+				w := penalty(feature, 0)
+				mean[feature] += w / float64(len(categories))
+				contributions[i] = append(contributions[i], Contribution{Feature: feature, Weight: w})
+			}
+		}
+		explanations[i] = Explanation{Category: category, LogScore: scores[i]}
+	}
+
+	normalize(scores)
+	for i := range explanations {
+		explanations[i].Probability = scores[i]
+		explanations[i].Supporting, explanations[i].Opposing = rank(contributions[i], mean)
+		c.attachDocuments(explanations[i].Category, explanations[i].Supporting)
+		c.attachDocuments(explanations[i].Category, explanations[i].Opposing)
+	}
+
+	sort.SliceStable(explanations, func(i, j int) bool {
+		if explanations[i].LogScore != explanations[j].LogScore {
+			return explanations[i].LogScore > explanations[j].LogScore
+		}
+		return explanations[i].Category < explanations[j].Category
+	})
+	return explanations, nil
+}
+
+// ExplainString provides convenience explanations for strings
+func (c *Classifier) ExplainString(doc string) ([]Explanation, error) {
+	return c.Explain(asReader(doc))
+}
+
+func (c *Classifier) attachDocuments(category string, contributions []Contribution) {
+	for i := range contributions {
+		contributions[i].Documents = c.documentIDs(contributions[i].Feature, category, maxContributions)
+	}
+}
+
+// rank splits contributions into those above and below the mean contribution
+// of each feature, ordered by the magnitude of their relative contribution
+func rank(contributions []Contribution, mean map[string]float64) ([]Contribution, []Contribution) {
+	var supporting, opposing []Contribution
+	for _, contribution := range contributions {
+		contribution.Relative = contribution.Weight - mean[contribution.Feature]
+		switch {
+		case contribution.Relative > 0:
+			supporting = append(supporting, contribution)
+		case contribution.Relative < 0:
+			opposing = append(opposing, contribution)
+		}
+	}
+
+	sort.Slice(supporting, func(i, j int) bool {
+		return byRelative(supporting[i], supporting[j], 1)
+	})
+	sort.Slice(opposing, func(i, j int) bool {
+		return byRelative(opposing[i], opposing[j], -1)
+	})
+	return limit(supporting), limit(opposing)
+}
+
+func byRelative(a, b Contribution, sign float64) bool {
+	if a.Relative != b.Relative {
+		return sign*a.Relative > sign*b.Relative
+	}
+	return a.Feature < b.Feature
+}
+
+func limit(contributions []Contribution) []Contribution {
+	if len(contributions) > maxContributions {
+		return contributions[:maxContributions]
+	}
+	return contributions
+}
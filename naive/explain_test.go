@@ -0,0 +1,76 @@
+package naive
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	classifier := New(Multinomial(1))
+	if _, err := classifier.ExplainString("cash"); err != ErrNotClassified {
+		t.Errorf("expected classification error; received: %v", err)
+	}
+
+	classifier.TrainString("earn cash prize online", "spam")
+	classifier.TrainString("meeting agenda online", "ham")
+
+	explanations, err := classifier.ExplainString("earn cash before the meeting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(explanations) != 2 || explanations[0].Category != "spam" {
+		t.Fatalf("expected spam to be explained first; got %v", explanations)
+	}
+
+	probabilities, _ := classifier.Probabilities("earn cash before the meeting")
+	for _, explanation := range explanations {
+		if math.Abs(explanation.Probability-probabilities[explanation.Category]) > 1e-9 {
+			t.Errorf("expected P(%s)=%f; got %f", explanation.Category, probabilities[explanation.Category], explanation.Probability)
+		}
+	}
+
+	spam := explanations[0]
+	if len(spam.Supporting) != 2 || spam.Supporting[0].Feature != "cash" && spam.Supporting[0].Feature != "earn" {
+		t.Errorf("expected earn and cash to support spam; got %v", spam.Supporting)
+	}
+	if len(spam.Opposing) != 1 || spam.Opposing[0].Feature != "meeting" {
+		t.Errorf("expected meeting to oppose spam; got %v", spam.Opposing)
+	}
+	for _, contribution := range spam.Supporting {
+		if contribution.Relative <= 0 || contribution.Weight >= 0 {
+			t.Errorf("unexpected contribution: %+v", contribution)
+		}
+	}
+}
+
+func TestExplainBernoulli(t *testing.T) {
+	classifier := New(Bernoulli(1))
+	classifier.TrainString("earn cash prize", "spam")
+	classifier.TrainString("meeting agenda", "ham")
+
+	explanations, err := classifier.ExplainString("meeting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, explanation := range explanations {
+		if explanation.Category != "spam" {
+			continue
+		}
+		absent := map[string]bool{}
+		for _, contribution := range explanation.Opposing {
+			if contribution.Count == 0 {
+				absent[contribution.Feature] = true
+				// log(1-2/3) for spam relative to the mean with log(1-1/3) for ham
+				if expected := (math.Log(1.0/3) - math.Log(2.0/3)) / 2; math.Abs(contribution.Relative-expected) > 1e-9 {
+					t.Errorf("expected %s to oppose spam by %f; got %f", contribution.Feature, expected, contribution.Relative)
+				}
+			}
+		}
+		if !absent["earn"] || !absent["cash"] || !absent["prize"] {
+			t.Errorf("expected absent spam terms to oppose spam; got %+v", explanation.Opposing)
+		}
+		if len(explanation.Supporting) == 0 || explanation.Supporting[0].Feature != "agenda" || explanation.Supporting[0].Count != 0 {
+			t.Errorf("expected the absence of agenda to support spam; got %+v", explanation.Supporting)
+		}
+	}
+}
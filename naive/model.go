@@ -0,0 +1,185 @@
+package naive
+
+import (
+	"math"
+	"sync"
+)
+
+// model provides a pluggable naive bayes event model. A document is scored
+// against a category as the sum of a document independent bias and the
+// weight of each of its features, all in log space.
+type model interface {
+	// score returns the log likelihood of a category before any features are
+	// observed, along with a function that weighs each observed feature
+	score(c *Classifier, category string) (float64, featureWeight)
+	// priors reports whether class priors contribute to document scores
+	priors() bool
+}
+
+// absenceModel is implemented by models that penalize a category for each
+// vocabulary term that is absent from a document. The penalties are part of the
+// bias returned by score; absent returns the penalty of each term, so that it
+// can be explained.
+type absenceModel interface {
+	absent(c *Classifier, category string) featureWeight
+}
+
+// featureWeight returns the log score contribution of a feature that occurs
+// count times within a document
+type featureWeight func(feature string, count int) float64
+
+// biasCache holds a document independent term of the model for each category,
+// which is expensive to compute and only changes when the counts of the
+// classifier change. It is safe for use by concurrent readers of the classifier.
+type biasCache struct {
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// get returns the cached value of category, computing it on first use
+func (b *biasCache) get(category string, compute func() float64) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if v, ok := b.values[category]; ok {
+		return v
+	}
+	if b.values == nil {
+		b.values = make(map[string]float64)
+	}
+	v := compute()
+	b.values[category] = v
+	return v
+}
+
+// reset discards every cached value; the caller must hold c.mu for writing
+func (b *biasCache) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.values = nil
+}
+
+// weighted is the default model, which weights each feature probability
+// towards an assumed probability until sufficient evidence has been observed
+type weighted struct{}
+
+func (weighted) score(c *Classifier, category string) (float64, featureWeight) {
+	return 0, func(feature string, count int) float64 {
+		return float64(count) * math.Log(c.weightedProbability(feature, category))
+	}
+}
+
+func (weighted) priors() bool {
+	return true
+}
+
+// multinomial models a document as a sequence of independent term draws
+// using additive smoothing; features outside of the training vocabulary
+// are ignored
+type multinomial struct {
+	alpha float64
+}
+
+func (m multinomial) score(c *Classifier, category string) (float64, featureWeight) {
+	denominator := c.tokenCount(category) + m.alpha*c.vocabularySize()
+	return 0, func(feature string, count int) float64 {
+		if _, ok := c.feat2cat[feature]; !ok {
+			return 0
+		}
+		numerator := c.featureCount(feature, category) + m.alpha
+		return float64(count) * math.Log(numerator/denominator)
+	}
+}
+
+func (multinomial) priors() bool {
+	return true
+}
+
+// complement estimates the parameters of each category from the training
+// data of every other category, which reduces the bias towards categories
+// with more training examples. Class priors are not used.
+type complement struct {
+	alpha     float64
+	normalize bool
+}
+
+func (m complement) score(c *Classifier, category string) (float64, featureWeight) {
+	denominator := c.totalTokens() - c.tokenCount(category) + m.alpha*c.vocabularySize()
+	logTheta := func(feature string) float64 {
+		return math.Log((c.complementCount(feature, category) + m.alpha) / denominator)
+	}
+
+	// the normalizer sums over the vocabulary, so it is cached between changes;
+	// it is zero when every feature is certain, in which case it is skipped
+	norm := -1.0
+	if m.normalize {
+		if sum := c.bias.get(category, func() float64 {
+			sum := 0.0
+			for feature := range c.feat2cat {
+				sum += logTheta(feature)
+			}
+			return sum
+		}); sum != 0 {
+			norm = sum
+		}
+	}
+
+	return 0, func(feature string, count int) float64 {
+		if _, ok := c.feat2cat[feature]; !ok {
+			return 0
+		}
+		return float64(count) * logTheta(feature) / norm
+	}
+}
+
+func (complement) priors() bool {
+	return false
+}
+
+// bernoulli models a document as a set of binary term occurrences using the
+// per category document frequency of each term. Every vocabulary term that is
+// absent from a document contributes log(1-p) to the score of a category.
+type bernoulli struct {
+	alpha float64
+}
+
+func (m bernoulli) score(c *Classifier, category string) (float64, featureWeight) {
+	probability := m.probability(c, category)
+
+	// assume every term is absent, then correct for the terms that are present;
+	// the bias sums over the vocabulary, so it is cached between changes
+	bias := c.bias.get(category, func() float64 {
+		sum := 0.0
+		for feature := range c.feat2doc {
+			sum += math.Log(1 - probability(feature))
+		}
+		return sum
+	})
+
+	return bias, func(feature string, _ int) float64 {
+		if _, ok := c.feat2doc[feature]; !ok {
+			return 0
+		}
+		p := probability(feature)
+		return math.Log(p) - math.Log(1-p)
+	}
+}
+
+func (m bernoulli) absent(c *Classifier, category string) featureWeight {
+	probability := m.probability(c, category)
+	return func(feature string, _ int) float64 {
+		return math.Log(1 - probability(feature))
+	}
+}
+
+// probability returns the smoothed probability that a document of category
+// contains a feature
+func (m bernoulli) probability(c *Classifier, category string) func(feature string) float64 {
+	documents := c.categoryCount(category)
+	return func(feature string) float64 {
+		return (c.documentCount(feature, category) + m.alpha) / (documents + 2*m.alpha)
+	}
+}
+
+func (bernoulli) priors() bool {
+	return true
+}
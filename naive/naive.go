@@ -2,8 +2,10 @@ package naive
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
+	"math"
 	"sync"
 
 	"github.com/n3integration/classifier"
@@ -12,30 +14,67 @@ import (
 // ErrNotClassified indicates that a document could not be classified
 var ErrNotClassified = errors.New("unable to classify document")
 
+var (
+	_ classifier.ProbabilisticClassifier = (*Classifier)(nil)
+	_ classifier.ContextClassifier       = (*Classifier)(nil)
+)
+
 // Option provides a functional setting for the Classifier
 type Option func(c *Classifier) error
 
 // Classifier implements a naive bayes classifier
 type Classifier struct {
 	feat2cat  map[string]map[string]int
+	feat2doc  map[string]map[string]int
 	catCount  map[string]int
+	catTokens map[string]int
+	docs      map[string]*example
+	feat2ids  map[string]map[string]struct{}
+	priors    map[string]float64
+	prior     priorStrategy
+	model     model
+	bias      biasCache
+	threshold classifier.Threshold
 	tokenizer classifier.Tokenizer
 	mu        sync.RWMutex
 }
 
-// New initializes a new naive Classifier using the standard tokenizer
+// New initializes a new naive Classifier using the standard tokenizer. Invalid
+// options are ignored; use NewClassifier to handle their errors.
 func New(opts ...Option) *Classifier {
-	c := &Classifier{
-		feat2cat:  make(map[string]map[string]int),
-		catCount:  make(map[string]int),
-		tokenizer: classifier.NewTokenizer(),
-	}
+	c := newClassifier()
 	for _, opt := range opts {
 		opt(c)
 	}
 	return c
 }
 
+// NewClassifier initializes a new naive Classifier like New, or returns the
+// error of the first invalid option
+func NewClassifier(opts ...Option) (*Classifier, error) {
+	c := newClassifier()
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func newClassifier() *Classifier {
+	return &Classifier{
+		feat2cat:  make(map[string]map[string]int),
+		feat2doc:  make(map[string]map[string]int),
+		catCount:  make(map[string]int),
+		catTokens: make(map[string]int),
+		docs:      make(map[string]*example),
+		feat2ids:  make(map[string]map[string]struct{}),
+		prior:     empiricalPriors,
+		model:     weighted{},
+		tokenizer: classifier.NewTokenizer(),
+	}
+}
+
 // Tokenizer overrides the classifier's default Tokenizer
 func Tokenizer(t classifier.Tokenizer) Option {
 	return func(c *Classifier) error {
@@ -44,16 +83,99 @@ func Tokenizer(t classifier.Tokenizer) Option {
 	}
 }
 
+// MinConfidence sets the minimum posterior probability required of the most
+// probable category. Less certain classifications return classifier.ErrLowConfidence.
+func MinConfidence(p float64) Option {
+	return func(c *Classifier) error {
+		if p < 0 || p > 1 {
+			return errors.New("the minimum confidence must be between 0 and 1")
+		}
+		c.threshold.MinConfidence = p
+		return nil
+	}
+}
+
+// MinMargin sets the minimum difference required between the posterior
+// probabilities of the two most probable categories. Less certain
+// classifications return classifier.ErrLowConfidence.
+func MinMargin(m float64) Option {
+	return func(c *Classifier) error {
+		if m < 0 || m > 1 {
+			return errors.New("the minimum margin must be between 0 and 1")
+		}
+		c.threshold.MinMargin = m
+		return nil
+	}
+}
+
+// Fallback provides a label that is returned in place of classifier.ErrLowConfidence
+func Fallback(category string) Option {
+	return func(c *Classifier) error {
+		c.threshold.Fallback = category
+		return nil
+	}
+}
+
+// Multinomial scores documents using a multinomial event model with additive
+// smoothing; alpha=1 provides Laplace smoothing and 0 < alpha < 1 provides
+// Lidstone smoothing
+func Multinomial(alpha float64) Option {
+	return func(c *Classifier) error {
+		if alpha <= 0 {
+			return errors.New("the smoothing parameter alpha must be positive")
+		}
+		c.model = multinomial{alpha: alpha}
+		return nil
+	}
+}
+
+// Complement scores documents using complement naive bayes, which estimates
+// the parameters of each category from all other categories and is better
+// suited to imbalanced training data. When normalize is set, the feature
+// weights of each category are normalized to reduce the influence of long
+// documents.
+func Complement(alpha float64, normalize bool) Option {
+	return func(c *Classifier) error {
+		if alpha <= 0 {
+			return errors.New("the smoothing parameter alpha must be positive")
+		}
+		c.model = complement{alpha: alpha, normalize: normalize}
+		return nil
+	}
+}
+
+// Bernoulli scores documents using a bernoulli event model, which considers
+// whether each vocabulary term is present in a document rather than how often
+// it occurs, and penalizes a category for terms that are absent. It is best
+// suited to short documents.
+func Bernoulli(alpha float64) Option {
+	return func(c *Classifier) error {
+		if alpha <= 0 {
+			return errors.New("the smoothing parameter alpha must be positive")
+		}
+		c.model = bernoulli{alpha: alpha}
+		return nil
+	}
+}
+
 // Train provides supervisory training to the classifier
 func (c *Classifier) Train(r io.Reader, category string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.TrainContext(context.Background(), r, category)
+}
 
-	for feature := range c.tokenizer.Tokenize(r) {
-		c.addFeature(feature, category)
+// TrainContext provides supervisory training to the classifier unless the
+// context is cancelled before the document has been tokenized, in which case
+// the classifier is left unchanged and the error of the context is returned
+func (c *Classifier) TrainContext(ctx context.Context, r io.Reader, category string) error {
+	features, err := c.features(ctx, r)
+	if err != nil {
+		return err
 	}
 
-	c.addCategory(category)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.train(features, category)
 	return nil
 }
 
@@ -62,54 +184,136 @@ func (c *Classifier) TrainString(doc string, category string) error {
 	return c.Train(asReader(doc), category)
 }
 
-// Classify attempts to classify a document. If the document cannot be classified
-// (eg. because the classifier has not been trained), an error is returned.
-func (c *Classifier) Classify(r io.Reader) (string, error) {
-	max := 0.0
-	var err error
-	classification := ""
-	probabilities := make(map[string]float64)
+// TrainDocument provides supervisory training to the classifier using an
+// identified document. The document ID must be unique and is surfaced by
+// explanations of the features that the document contains.
+func (c *Classifier) TrainDocument(doc classifier.Document, r io.Reader, category string) error {
+	if doc.ID == "" {
+		return classifier.ErrMissingDocumentID
+	}
 
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	features, err := c.features(context.Background(), r)
+	if err != nil {
+		return err
+	}
 
-	for _, category := range c.categories() {
-		probabilities[category] = c.probability(r, category)
-		if probabilities[category] > max {
-			max = probabilities[category]
-			classification = category
-		}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.docs[doc.ID]; ok {
+		return classifier.ErrDuplicateDocument
 	}
 
-	if classification == "" {
+	c.train(features, category)
+	c.addExample(doc.ID, &example{
+		Category: category,
+		Metadata: doc.Metadata,
+		Features: features,
+	})
+	return nil
+}
+
+func (c *Classifier) train(features map[string]int, category string) {
+	for feature, count := range features {
+		c.addFeatureCount(feature, category, count)
+		c.addDocument(feature, category)
+		c.catTokens[category] += count
+	}
+	c.addCategory(category)
+	c.bias.reset()
+}
+
+// Classify attempts to classify a document. If the document cannot be classified
+// (eg. because the classifier has not been trained), an error is returned. If the
+// most probable category does not satisfy the configured confidence thresholds,
+// classifier.ErrLowConfidence or the configured fallback label is returned.
+func (c *Classifier) Classify(r io.Reader) (string, error) {
+	return c.ClassifyContext(context.Background(), r)
+}
+
+// ClassifyContext classifies a document like Classify, but stops tokenizing and
+// scoring once the context is cancelled and returns the error of the context
+func (c *Classifier) ClassifyContext(ctx context.Context, r io.Reader) (string, error) {
+	scores, err := c.scores(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	if scores[0].Score == 0 {
 		return "", ErrNotClassified
 	}
-	return classification, err
+	return c.threshold.Decide(scores)
 }
 
 // Probabilities runs the provided string through the model and returns
-// the potential probability for each classification
+// the normalized posterior probability for each classification. If the
+// tokenizer fails, such as for a token that exceeds its maximum size, an empty
+// map and category are returned rather than scoring a truncated document; use
+// ScoresString to handle the error.
 func (c *Classifier) Probabilities(str string) (map[string]float64, string) {
-	probabilities := make(map[string]float64)
+	scores := make(map[string]float64)
+	doc, err := c.features(context.Background(), asReader(str))
+	if err != nil {
+		return scores, ``
+	}
 
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	best := 0.0
+	best := math.Inf(-1)
 	cat := ``
 
 	for _, category := range c.categories() {
-		prob := c.probability(asReader(str), category)
-		if prob > 0 {
-			probabilities[category] = prob
-		}
-		if prob > best {
-			best = prob
+		score := c.logProbability(doc, category)
+		scores[category] = score
+		if score > best {
+			best = score
 			cat = category
 		}
 	}
 
-	return probabilities, cat
+	return softmax(scores), cat
+}
+
+// Scores returns the normalized posterior probability of every category,
+// ordered from the most to the least likely
+func (c *Classifier) Scores(r io.Reader) ([]classifier.Score, error) {
+	return c.scores(context.Background(), r)
+}
+
+func (c *Classifier) scores(ctx context.Context, r io.Reader) ([]classifier.Score, error) {
+	doc, err := c.features(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	categories := c.categories()
+	if len(categories) == 0 {
+		return nil, ErrNotClassified
+	}
+
+	values := make([]float64, len(categories))
+	for i, category := range categories {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		values[i] = c.logProbability(doc, category)
+	}
+	normalize(values)
+
+	scores := make([]classifier.Score, len(categories))
+	for i, category := range categories {
+		scores[i] = classifier.Score{Category: category, Score: values[i]}
+	}
+	classifier.SortScores(scores)
+	return scores, nil
+}
+
+// ScoresString provides convenience scoring for strings
+func (c *Classifier) ScoresString(doc string) ([]classifier.Score, error) {
+	return c.Scores(asReader(doc))
 }
 
 // ClassifyString provides convenience classification for strings
@@ -118,10 +322,14 @@ func (c *Classifier) ClassifyString(doc string) (string, error) {
 }
 
 func (c *Classifier) addFeature(feature string, category string) {
+	c.addFeatureCount(feature, category, 1)
+}
+
+func (c *Classifier) addFeatureCount(feature string, category string, count int) {
 	if _, ok := c.feat2cat[feature]; !ok {
 		c.feat2cat[feature] = make(map[string]int)
 	}
-	c.feat2cat[feature][category]++
+	c.feat2cat[feature][category] += count
 }
 
 func (c *Classifier) featureCount(feature string, category string) float64 {
@@ -131,6 +339,21 @@ func (c *Classifier) featureCount(feature string, category string) float64 {
 	return 0.0
 }
 
+func (c *Classifier) addDocument(feature string, category string) {
+	if _, ok := c.feat2doc[feature]; !ok {
+		c.feat2doc[feature] = make(map[string]int)
+	}
+	c.feat2doc[feature][category]++
+}
+
+// documentCount returns the number of documents within category that contain feature
+func (c *Classifier) documentCount(feature string, category string) float64 {
+	if _, ok := c.feat2doc[feature]; ok {
+		return float64(c.feat2doc[feature][category])
+	}
+	return 0.0
+}
+
 func (c *Classifier) addCategory(category string) {
 	c.catCount[category]++
 }
@@ -178,18 +401,78 @@ func (c *Classifier) variableWeightedProbability(feature string, category string
 	return ((weight * assumedProb) + (sum * probability)) / (weight + sum)
 }
 
-func (c *Classifier) probability(r io.Reader, category string) float64 {
-	categoryProbability := c.categoryCount(category) / float64(c.count())
-	docProbability := c.docProbability(r, category)
-	return docProbability * categoryProbability
+// logProbability returns the unnormalized log posterior of category for the
+// document; scores are summed in log space to avoid underflow on long documents
+func (c *Classifier) logProbability(doc map[string]int, category string) float64 {
+	if !c.model.priors() {
+		return c.logLikelihood(doc, category)
+	}
+	return c.logPrior(category) + c.logLikelihood(doc, category)
+}
+
+func (c *Classifier) logLikelihood(doc map[string]int, category string) float64 {
+	likelihood, weight := c.model.score(c, category)
+	for feature, count := range doc {
+		likelihood += weight(feature, count)
+	}
+	return likelihood
+}
+
+func (c *Classifier) tokenCount(category string) float64 {
+	return float64(c.catTokens[category])
 }
 
-func (c *Classifier) docProbability(r io.Reader, category string) float64 {
-	probability := 1.0
-	for feature := range c.tokenizer.Tokenize(r) {
-		probability *= c.weightedProbability(feature, category)
+func (c *Classifier) totalTokens() float64 {
+	sum := 0
+	for _, value := range c.catTokens {
+		sum += value
+	}
+	return float64(sum)
+}
+
+// complementCount returns the number of occurrences of feature across every
+// category other than the provided category
+func (c *Classifier) complementCount(feature string, category string) float64 {
+	sum := 0
+	for cat, count := range c.feat2cat[feature] {
+		if cat != category {
+			sum += count
+		}
+	}
+	return float64(sum)
+}
+
+func (c *Classifier) vocabularySize() float64 {
+	return float64(len(c.feat2cat))
+}
+
+// features tokenizes the document once into a bag of feature counts, so that
+// every category is scored against the same input. The error of the context or
+// the tokenizer is returned if tokenization ended early.
+func (c *Classifier) features(ctx context.Context, r io.Reader) (map[string]int, error) {
+	doc := make(map[string]int)
+	tokens, wait := classifier.TokenizeChecked(ctx, c.tokenizer, r)
+	for feature := range tokens {
+		doc[feature]++
+	}
+	return doc, wait()
+}
+
+// softmax converts log scores into a normalized probability distribution
+func softmax(scores map[string]float64) map[string]float64 {
+	categories := make([]string, 0, len(scores))
+	values := make([]float64, 0, len(scores))
+	for category, score := range scores {
+		categories = append(categories, category)
+		values = append(values, score)
+	}
+
+	normalize(values)
+	probabilities := make(map[string]float64, len(scores))
+	for i, category := range categories {
+		probabilities[category] = values[i]
 	}
-	return probability
+	return probabilities
 }
 
 func asReader(text string) io.Reader {
@@ -1,7 +1,15 @@
 package naive
 
 import (
+	"bufio"
+	"context"
+	"math"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/n3integration/classifier"
+	"github.com/n3integration/classifier/internal/leaktest"
 )
 
 var (
@@ -31,6 +39,212 @@ func TestProbability(t *testing.T) {
 		}
 	})
 }
+
+func TestLongDocument(t *testing.T) {
+	classifier := New()
+	classifier.TrainString(ham, "good")
+	classifier.TrainString(spam, "bad")
+
+	doc := strings.Repeat("earn cash online ", 500)
+	if category, err := classifier.ClassifyString(doc); err != nil || category != "bad" {
+		t.Fatalf("expected long document to be classified as bad; got %q (%v)", category, err)
+	}
+
+	probabilities, category := classifier.Probabilities(doc)
+	if category != "bad" {
+		t.Errorf("expected most probable category to be bad; got %q", category)
+	}
+
+	sum := 0.0
+	for _, p := range probabilities {
+		sum += p
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("expected probabilities to sum to one; got %f", sum)
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	for _, opt := range []Option{Multinomial(0), Complement(-1, true), Bernoulli(0), MinConfidence(5), MinMargin(-1)} {
+		if c, err := NewClassifier(opt); err == nil || c != nil {
+			t.Errorf("expected an error for an invalid option; got %v", err)
+		}
+	}
+	if _, err := NewClassifier(Multinomial(1), MinConfidence(0.5)); err != nil {
+		t.Errorf("expected valid options to be accepted; got %v", err)
+	}
+
+	if _, ok := New(Multinomial(0)).model.(weighted); !ok {
+		t.Error("expected New to ignore an invalid option")
+	}
+}
+
+func TestMultinomial(t *testing.T) {
+	classifier := New(Multinomial(1))
+	classifier.TrainString("apple apple banana", "A")
+	classifier.TrainString("banana cherry", "X")
+
+	// P(apple|A) = (2+1)/(3+3) and P(apple|X) = (0+1)/(2+3) with equal priors
+	probabilities, category := classifier.Probabilities("apple unseen")
+	if category != "A" {
+		t.Errorf("expected category A; got %q", category)
+	}
+	if math.Abs(probabilities["A"]-0.25/0.35) > 1e-9 {
+		t.Errorf("expected P(A)=%.4f; got %.4f", 0.25/0.35, probabilities["A"])
+	}
+
+	if category, _ := classifier.ClassifyString("cherry banana"); category != "X" {
+		t.Errorf("expected category X; got %q", category)
+	}
+}
+
+func TestComplement(t *testing.T) {
+	for _, normalize := range []bool{false, true} {
+		classifier := New(Complement(1, normalize))
+		classifier.TrainString("apple apple banana", "A")
+		classifier.TrainString("banana cherry", "X")
+
+		// weight(apple|A) = -log((0+1)/(2+3)) and weight(apple|X) = -log((2+1)/(3+3))
+		probabilities, category := classifier.Probabilities("apple")
+		if category != "A" {
+			t.Errorf("expected category A; got %q", category)
+		}
+		if !normalize {
+			expected := 1 / (1 + math.Exp(math.Log(0.2)-math.Log(0.5)))
+			if math.Abs(probabilities["A"]-expected) > 1e-9 {
+				t.Errorf("expected P(A)=%.4f; got %.4f", expected, probabilities["A"])
+			}
+		}
+	}
+}
+
+func TestComplementZeroNormalizer(t *testing.T) {
+	// with a single term vocabulary every complement estimate is certain
+	classifier := New(Complement(1, true))
+	classifier.TrainString("apple", "A")
+	classifier.TrainString("apple", "X")
+
+	scores, err := classifier.ScoresString("apple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, score := range scores {
+		if math.IsNaN(score.Score) {
+			t.Errorf("expected a finite score; got %v", scores)
+		}
+	}
+	if _, err := classifier.ClassifyString("apple"); err != nil {
+		t.Errorf("expected a classification; got %v", err)
+	}
+}
+
+func TestComplementImbalanced(t *testing.T) {
+	classifier := New(Complement(1, true))
+	for i := 0; i < 20; i++ {
+		classifier.TrainString("meeting agenda notes schedule project update review", "ham")
+	}
+	classifier.TrainString("winner prize claim cash", "spam")
+
+	if category, err := classifier.ClassifyString("claim your cash prize before the meeting"); err != nil || category != "spam" {
+		t.Errorf("expected minority category spam; got %q (%v)", category, err)
+	}
+}
+
+func TestCachedBias(t *testing.T) {
+	for _, opt := range []Option{Complement(1, true), Bernoulli(1)} {
+		incremental, batch, single := New(opt), New(opt), New(opt)
+		incremental.TrainString("apple banana", "A")
+		incremental.TrainString("cherry", "X")
+		incremental.ClassifyString("apple")
+		incremental.TrainString("apple durian", "X")
+
+		batch.TrainString("apple banana", "A")
+		batch.TrainString("cherry", "X")
+		batch.TrainString("apple durian", "X")
+		assertSameProbabilities(t, incremental, batch, "apple cherry")
+
+		incremental.UntrainString("apple durian", "X")
+		single.TrainString("apple banana", "A")
+		single.TrainString("cherry", "X")
+		assertSameProbabilities(t, incremental, single, "apple cherry")
+	}
+}
+
+func assertSameProbabilities(t *testing.T, actual, expected *Classifier, doc string) {
+	t.Helper()
+	want, _ := expected.Probabilities(doc)
+	got, _ := actual.Probabilities(doc)
+	for category, p := range want {
+		if math.Abs(got[category]-p) > 1e-12 {
+			t.Errorf("expected P(%s)=%.6f; got %.6f", category, p, got[category])
+		}
+	}
+}
+
+func TestBernoulli(t *testing.T) {
+	classifier := New(Bernoulli(1))
+	classifier.TrainString("apple banana", "A")
+	classifier.TrainString("apple apple banana", "A")
+	classifier.TrainString("apple", "X")
+	classifier.TrainString("apple apple", "X")
+
+	assertEqual(t, 2, classifier.documentCount("apple", "X"))
+
+	// the absence of banana penalizes A: P(A) = .75*.25 and P(X) = .75*.75
+	probabilities, category := classifier.Probabilities("apple apple apple")
+	if category != "X" {
+		t.Errorf("expected category X; got %q", category)
+	}
+	if math.Abs(probabilities["X"]-0.75) > 1e-9 {
+		t.Errorf("expected P(X)=0.75; got %.4f", probabilities["X"])
+	}
+}
+
+func TestScores(t *testing.T) {
+	classifier := New()
+	if _, err := classifier.ScoresString("cash"); err != ErrNotClassified {
+		t.Errorf("expected classification error; received: %v", err)
+	}
+
+	classifier.TrainString(`aaa bbb ccc ddd`, "A")
+	classifier.TrainString(`111 222 333 444`, "X")
+	classifier.TrainString(`lorem ipsum dolor amet`, "L")
+
+	scores, err := classifier.ScoresString(`ipsum dolor 222`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 3 || scores[0].Category != "L" || scores[1].Category != "X" {
+		t.Fatalf("expected categories ranked L, X, A; got %v", scores)
+	}
+
+	sum := 0.0
+	for _, score := range scores {
+		sum += score.Score
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("expected scores to sum to one; got %f", sum)
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	train := func(c *Classifier) *Classifier {
+		c.TrainString(`aaa bbb ccc ddd`, "A")
+		c.TrainString(`111 222 333 444`, "X")
+		return c
+	}
+
+	if category, err := train(New(MinConfidence(0.9))).ClassifyString(`aaa bbb ccc`); err != nil || category != "A" {
+		t.Errorf("expected confident classification; got %q (%v)", category, err)
+	}
+	if _, err := train(New(MinConfidence(0.9))).ClassifyString(`aaa 222`); err != classifier.ErrLowConfidence {
+		t.Errorf("expected ErrLowConfidence; got %v", err)
+	}
+	if category, err := train(New(MinMargin(0.5), Fallback("unknown"))).ClassifyString(`aaa 222`); err != nil || category != "unknown" {
+		t.Errorf("expected fallback category; got %q (%v)", category, err)
+	}
+}
+
 func TestAddFeature(t *testing.T) {
 	classifier := New()
 	classifier.addFeature("quick", "good")
@@ -88,6 +302,85 @@ func TestClassify(t *testing.T) {
 	})
 }
 
+func TestClassifyReader(t *testing.T) {
+	classifier := New()
+	classifier.TrainString(`aaa bbb ccc ddd`, "A")
+	classifier.TrainString(`111 222 333 444`, "X")
+	classifier.TrainString(`lorem ipsum dolor amet`, "L")
+
+	tests := []struct {
+		Doc      string
+		Expected string
+	}{
+		{`bbb ccc ddd`, "A"},
+		{`222 333 444`, "X"},
+		{`ipsum dolor amet`, "L"},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 10; i++ {
+			category, err := classifier.Classify(strings.NewReader(test.Doc))
+			if err != nil {
+				t.Fatalf("failed to classify %q: %v", test.Doc, err)
+			}
+			if category != test.Expected {
+				t.Fatalf("incorrectly classified %q; expected %s, but got %s", test.Doc, test.Expected, category)
+			}
+		}
+	}
+}
+
+func TestNGrams(t *testing.T) {
+	classifier := New(Tokenizer(classifier.NewTokenizer(classifier.NGrams(1, 2), classifier.Filters())))
+	classifier.TrainString("a good movie", "positive")
+	classifier.TrainString("not a good movie", "negative")
+
+	assertFeatureCount(t, classifier, "not a", "negative", 1)
+	assertFeatureCount(t, classifier, "good movie", "positive", 1)
+	if category, err := classifier.ClassifyString("not a good film"); err != nil || category != "negative" {
+		t.Errorf("expected category negative; got %q (%v)", category, err)
+	}
+}
+
+func TestTokenizerError(t *testing.T) {
+	classifier := New(Tokenizer(classifier.NewTokenizer(classifier.MaxTokenSize(16))))
+	long := ham + " " + strings.Repeat("x", 32)
+
+	if err := classifier.TrainString(long, "good"); err != bufio.ErrTooLong {
+		t.Errorf("expected bufio.ErrTooLong; got %v", err)
+	}
+	assertCategoryCount(t, classifier, "good", 0)
+
+	classifier.TrainString(ham, "good")
+	if _, err := classifier.ClassifyString(long); err != bufio.ErrTooLong {
+		t.Errorf("expected bufio.ErrTooLong; got %v", err)
+	}
+	if probabilities, category := classifier.Probabilities(long); len(probabilities) != 0 || category != "" {
+		t.Errorf("expected a truncated document to not be scored; got %q %v", category, probabilities)
+	}
+}
+
+func TestContext(t *testing.T) {
+	defer leaktest.Check(t)()
+	classifier := New()
+	classifier.TrainString(ham, "good")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := classifier.TrainContext(ctx, leaktest.Endless{}, "bad"); err != context.DeadlineExceeded {
+		t.Errorf("expected training to time out; got %v", err)
+	}
+	assertCategoryCount(t, classifier, "bad", 0)
+
+	if _, err := classifier.ClassifyContext(ctx, strings.NewReader(ham)); err != context.DeadlineExceeded {
+		t.Errorf("expected classification to time out; got %v", err)
+	}
+	if category, err := classifier.ClassifyContext(context.Background(), strings.NewReader(ham)); err != nil || category != "good" {
+		t.Errorf("expected category good; got %q (%v)", category, err)
+	}
+
+}
+
 func assertCategoryCount(t *testing.T, classifier *Classifier, category string, count float64) {
 	v := classifier.categoryCount(category)
 	assertEqual(t, count, v)
@@ -0,0 +1,230 @@
+package naive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/n3integration/classifier"
+)
+
+// formatVersion is the current version of the persisted model format
+const formatVersion = 2
+
+// ErrCorruptModel indicates that a persisted model contains invalid counts or
+// parameters
+var ErrCorruptModel = errors.New("corrupt naive bayes model")
+
+// persisted provides the on-disk representation of a naive Classifier. Models
+// are stored as a single JSON document with the following fields:
+//
+//	version    format version; currently 2
+//	tokenizer  fingerprint of the tokenizer used during training
+//	model      event model name (weighted, multinomial, complement, or bernoulli)
+//	           along with its smoothing and normalization parameters
+//	prior      prior strategy (empirical, uniform, or fixed) and fixed priors
+//	categories number of training documents per category
+//	tokens     number of training tokens per category
+//	features   number of occurrences of each feature per category
+//	documents  number of training documents containing each feature per category
+//	examples   identified training documents by ID, with their category, metadata,
+//	           and feature counts (since version 2)
+type persisted struct {
+	Version    int                       `json:"version"`
+	Tokenizer  string                    `json:"tokenizer"`
+	Model      persistedModel            `json:"model"`
+	Prior      persistedPrior            `json:"prior"`
+	Categories map[string]int            `json:"categories"`
+	Tokens     map[string]int            `json:"tokens"`
+	Features   map[string]map[string]int `json:"features"`
+	Documents  map[string]map[string]int `json:"documents"`
+	Examples   map[string]*example       `json:"examples,omitempty"`
+}
+
+type persistedModel struct {
+	Name      string  `json:"name"`
+	Alpha     float64 `json:"alpha,omitempty"`
+	Normalize bool    `json:"normalize,omitempty"`
+}
+
+type persistedPrior struct {
+	Strategy string             `json:"strategy"`
+	Priors   map[string]float64 `json:"priors,omitempty"`
+}
+
+var priorNames = map[priorStrategy]string{
+	empiricalPriors: "empirical",
+	uniformPriors:   "uniform",
+	fixedPriors:     "fixed",
+}
+
+// Save writes the trained model to w, so that it can be restored with Load
+// without replaying the training data
+func (c *Classifier) Save(w io.Writer) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return json.NewEncoder(w).Encode(&persisted{
+		Version:    formatVersion,
+		Tokenizer:  classifier.Fingerprint(c.tokenizer),
+		Model:      encodeModel(c.model),
+		Prior:      persistedPrior{Strategy: priorNames[c.prior], Priors: c.priors},
+		Categories: c.catCount,
+		Tokens:     c.catTokens,
+		Features:   c.feat2cat,
+		Documents:  c.feat2doc,
+		Examples:   c.docs,
+	})
+}
+
+// Load replaces the state of the classifier with a model previously written by
+// Save. The event model and priors are restored from the persisted model. If the
+// model was saved with a tokenizer fingerprint that does not match the
+// classifier's tokenizer, classifier.ErrTokenizerMismatch is returned and the
+// classifier is left unchanged. Models with negative counts, smoothing parameters
+// that are not positive, or invalid fixed priors return ErrCorruptModel.
+func (c *Classifier) Load(r io.Reader) error {
+	var p persisted
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return fmt.Errorf("failed to decode model: %w", err)
+	}
+	if p.Version < 1 || p.Version > formatVersion {
+		return fmt.Errorf("unsupported model version: %d", p.Version)
+	}
+
+	m, err := decodeModel(p.Model)
+	if err != nil {
+		return err
+	}
+
+	prior, priors, err := decodePrior(p.Prior)
+	if err != nil {
+		return err
+	}
+	if !validCounts(&p) {
+		return ErrCorruptModel
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p.Tokenizer != "" && p.Tokenizer != classifier.Fingerprint(c.tokenizer) {
+		return classifier.ErrTokenizerMismatch
+	}
+
+	c.model = m
+	c.prior = prior
+	c.priors = priors
+	c.catCount = orEmpty(p.Categories)
+	c.catTokens = orEmpty(p.Tokens)
+	c.feat2cat = orEmptyNested(p.Features)
+	c.feat2doc = orEmptyNested(p.Documents)
+	c.bias.reset()
+	c.docs = make(map[string]*example, len(p.Examples))
+	c.feat2ids = make(map[string]map[string]struct{})
+	for id, e := range p.Examples {
+		c.addExample(id, e)
+	}
+	return nil
+}
+
+func encodeModel(m model) persistedModel {
+	switch v := m.(type) {
+	case multinomial:
+		return persistedModel{Name: "multinomial", Alpha: v.alpha}
+	case complement:
+		return persistedModel{Name: "complement", Alpha: v.alpha, Normalize: v.normalize}
+	case bernoulli:
+		return persistedModel{Name: "bernoulli", Alpha: v.alpha}
+	default:
+		return persistedModel{Name: "weighted"}
+	}
+}
+
+func decodeModel(p persistedModel) (model, error) {
+	var m model
+	switch p.Name {
+	case "weighted":
+		return weighted{}, nil
+	case "multinomial":
+		m = multinomial{alpha: p.Alpha}
+	case "complement":
+		m = complement{alpha: p.Alpha, normalize: p.Normalize}
+	case "bernoulli":
+		m = bernoulli{alpha: p.Alpha}
+	default:
+		return nil, fmt.Errorf("unsupported model: %q", p.Name)
+	}
+	// smoothed models require a positive alpha, as their options do
+	if !(p.Alpha > 0) {
+		return nil, ErrCorruptModel
+	}
+	return m, nil
+}
+
+func decodePrior(p persistedPrior) (priorStrategy, map[string]float64, error) {
+	for strategy, name := range priorNames {
+		if name != p.Strategy {
+			continue
+		}
+		if strategy != fixedPriors {
+			return strategy, nil, nil
+		}
+		priors, err := normalizePriors(p.Priors)
+		if err != nil {
+			return 0, nil, ErrCorruptModel
+		}
+		return strategy, priors, nil
+	}
+	return 0, nil, fmt.Errorf("unsupported prior strategy: %q", p.Strategy)
+}
+
+// validCounts reports whether every count of the persisted model is possible;
+// categories, features, and documents are pruned once their count reaches zero
+func validCounts(p *persisted) bool {
+	for _, count := range p.Categories {
+		if count < 1 {
+			return false
+		}
+	}
+	for _, count := range p.Tokens {
+		if count < 0 {
+			return false
+		}
+	}
+	for _, counts := range []map[string]map[string]int{p.Features, p.Documents} {
+		for _, categories := range counts {
+			for _, count := range categories {
+				if count < 1 {
+					return false
+				}
+			}
+		}
+	}
+	for _, e := range p.Examples {
+		if e == nil {
+			return false
+		}
+		for _, count := range e.Features {
+			if count < 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func orEmpty(m map[string]int) map[string]int {
+	if m == nil {
+		return make(map[string]int)
+	}
+	return m
+}
+
+func orEmptyNested(m map[string]map[string]int) map[string]map[string]int {
+	if m == nil {
+		return make(map[string]map[string]int)
+	}
+	return m
+}
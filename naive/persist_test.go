@@ -0,0 +1,101 @@
+package naive
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/n3integration/classifier"
+)
+
+func TestSaveLoad(t *testing.T) {
+	trained := New(Multinomial(0.5), Priors(map[string]float64{"good": 1, "bad": 3}))
+	trained.TrainString(ham, "good")
+	trained.TrainString(spam, "bad")
+
+	var buf bytes.Buffer
+	if err := trained.Save(&buf); err != nil {
+		t.Fatal("failed to save model:", err)
+	}
+
+	loaded := New()
+	if err := loaded.Load(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal("failed to load model:", err)
+	}
+
+	doc := "quick cash online"
+	expected, _ := trained.Probabilities(doc)
+	actual, _ := loaded.Probabilities(doc)
+	for category, p := range expected {
+		if math.Abs(p-actual[category]) > 1e-12 {
+			t.Errorf("expected P(%s)=%f; got %f", category, p, actual[category])
+		}
+	}
+
+	t.Run("Tokenizer mismatch", func(t *testing.T) {
+		other := New(Tokenizer(classifier.NewTokenizer(classifier.Filters(classifier.IsWord))))
+		if err := other.Load(bytes.NewReader(buf.Bytes())); err != classifier.ErrTokenizerMismatch {
+			t.Errorf("expected tokenizer mismatch; got %v", err)
+		}
+		if _, err := other.ClassifyString(doc); err != ErrNotClassified {
+			t.Errorf("expected mismatched model to remain untrained; got %v", err)
+		}
+	})
+
+	t.Run("Unsupported version", func(t *testing.T) {
+		if err := New().Load(bytes.NewBufferString(`{"version": 99}`)); err == nil {
+			t.Error("expected an unsupported version error")
+		}
+	})
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	trained := New(Multinomial(1))
+	trained.TrainString(ham, "good")
+	trained.TrainString(spam, "bad")
+	var buf bytes.Buffer
+	if err := trained.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name    string
+		Corrupt func(p *persisted)
+	}{
+		{"Alpha", func(p *persisted) { p.Model.Alpha = 0 }},
+		{"Category Count", func(p *persisted) { p.Categories["good"] = -1 }},
+		{"Token Count", func(p *persisted) { p.Tokens["good"] = -1 }},
+		{"Feature Count", func(p *persisted) { p.Features["cash"]["bad"] = -1 }},
+		{"Document Count", func(p *persisted) { p.Documents["cash"]["bad"] = 0 }},
+		{"Fixed Priors", func(p *persisted) { p.Prior = persistedPrior{Strategy: "fixed"} }},
+		{"Invalid Priors", func(p *persisted) {
+			p.Prior = persistedPrior{Strategy: "fixed", Priors: map[string]float64{"good": -1}}
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var p persisted
+			if err := json.Unmarshal(buf.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			test.Corrupt(&p)
+			corrupt, _ := json.Marshal(&p)
+
+			c := New()
+			if err := c.Load(bytes.NewReader(corrupt)); err != ErrCorruptModel {
+				t.Errorf("expected corrupt model; got %v", err)
+			}
+			if _, err := c.ClassifyString(spam); err != ErrNotClassified {
+				t.Errorf("expected corrupt model to leave the classifier untrained; got %v", err)
+			}
+		})
+	}
+
+	for _, name := range []string{`"model": {"name": "unknown"}`, `"model": {"name": "weighted"}, "prior": {"strategy": "unknown"}`} {
+		if err := New().Load(bytes.NewBufferString(`{"version": 2, ` + name + `}`)); err == nil || err == ErrCorruptModel {
+			t.Errorf("expected an unsupported name error for %s; got %v", name, err)
+		}
+	}
+}
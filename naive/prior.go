@@ -0,0 +1,231 @@
+package naive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	maxPriorIterations = 100
+	priorTolerance     = 1e-6
+)
+
+// priorStrategy determines how class priors are derived
+type priorStrategy int
+
+const (
+	// empiricalPriors derives priors from the number of training documents per category
+	empiricalPriors priorStrategy = iota
+	// uniformPriors assigns every category the same prior
+	uniformPriors
+	// fixedPriors uses explicitly supplied priors
+	fixedPriors
+)
+
+// ErrInvalidPriors indicates that the supplied class priors are not a valid distribution
+var ErrInvalidPriors = errors.New("priors must be non-negative with a positive sum")
+
+// UniformPriors assigns every category the same prior probability, regardless
+// of the number of training documents within each category
+func UniformPriors() Option {
+	return func(c *Classifier) error {
+		c.prior = uniformPriors
+		c.priors = nil
+		return nil
+	}
+}
+
+// Priors overrides the class priors derived from training data. The supplied
+// values are normalized; categories without a prior are never selected.
+func Priors(priors map[string]float64) Option {
+	return func(c *Classifier) error {
+		return c.setPriors(priors)
+	}
+}
+
+// SetPriors replaces the class priors of a trained classifier without retraining.
+// The supplied values are normalized; a nil map restores the priors derived from
+// training data.
+func (c *Classifier) SetPriors(priors map[string]float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.setPriors(priors)
+}
+
+// ErrCategoriesChanged indicates that categories were trained or forgotten while
+// priors were being estimated from a stream of documents
+var ErrCategoriesChanged = errors.New("the categories of the classifier changed while estimating priors")
+
+// EstimatePriors re-estimates the class priors from a set of unlabeled documents
+// using expectation maximization, which adjusts a trained model to a deployment
+// environment whose category distribution differs from the training data. The
+// estimated priors are applied to the classifier and returned.
+func (c *Classifier) EstimatePriors(docs ...io.Reader) (map[string]float64, error) {
+	stream := make(chan io.Reader, len(docs))
+	for _, r := range docs {
+		stream <- r
+	}
+	close(stream)
+	return c.EstimatePriorsStream(stream)
+}
+
+// EstimatePriorsStream re-estimates the class priors like EstimatePriors from the
+// unlabeled documents received until docs is closed. Each document is scored as
+// it arrives, so only its likelihood under each category is retained rather than
+// the document itself. Documents are tokenized without locking the classifier,
+// which continues to classify while the stream is read. If the categories of the
+// classifier change before the stream is closed, ErrCategoriesChanged is returned
+// and the priors are left unchanged. The stream is not drained after an error.
+func (c *Classifier) EstimatePriorsStream(docs <-chan io.Reader) (map[string]float64, error) {
+	c.mu.RLock()
+	usesPriors := c.model.priors()
+	categories := c.categories()
+	c.mu.RUnlock()
+
+	if !usesPriors {
+		return nil, errors.New("the classifier model does not use class priors")
+	}
+	if len(categories) == 0 {
+		return nil, ErrNotClassified
+	}
+
+	var likelihoods [][]float64
+	for r := range docs {
+		doc, err := c.features(context.Background(), r)
+		if err != nil {
+			return nil, err
+		}
+		likelihood := make([]float64, len(categories))
+		c.mu.RLock()
+		for j, category := range categories {
+			likelihood[j] = c.logLikelihood(doc, category)
+		}
+		c.mu.RUnlock()
+		likelihoods = append(likelihoods, likelihood)
+	}
+	if len(likelihoods) == 0 {
+		return nil, errors.New("at least one document is required to estimate priors")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.catCount) != len(categories) {
+		return nil, ErrCategoriesChanged
+	}
+	priors := make([]float64, len(categories))
+	for j, category := range categories {
+		if _, ok := c.catCount[category]; !ok {
+			return nil, ErrCategoriesChanged
+		}
+		priors[j] = math.Exp(c.logPrior(category))
+	}
+	priors = maximizePriors(likelihoods, priors)
+
+	result := make(map[string]float64, len(categories))
+	for j, category := range categories {
+		result[category] = priors[j]
+	}
+	return result, c.setPriors(result)
+}
+
+// maximizePriors iterates expectation maximization from the initial priors over
+// the log likelihood of each document under each category
+func maximizePriors(likelihoods [][]float64, priors []float64) []float64 {
+	posterior := make([]float64, len(priors))
+	for iter := 0; iter < maxPriorIterations; iter++ {
+		estimate := make([]float64, len(priors))
+		for _, likelihood := range likelihoods {
+			for j := range priors {
+				posterior[j] = math.Log(priors[j]) + likelihood[j]
+			}
+			normalize(posterior)
+			for j := range priors {
+				estimate[j] += posterior[j] / float64(len(likelihoods))
+			}
+		}
+
+		delta := 0.0
+		for j := range priors {
+			delta = math.Max(delta, math.Abs(estimate[j]-priors[j]))
+		}
+		priors = estimate
+		if delta < priorTolerance {
+			break
+		}
+	}
+	return priors
+}
+
+func (c *Classifier) setPriors(priors map[string]float64) error {
+	if priors == nil {
+		c.prior = empiricalPriors
+		c.priors = nil
+		return nil
+	}
+
+	normalized, err := normalizePriors(priors)
+	if err != nil {
+		return err
+	}
+	c.prior = fixedPriors
+	c.priors = normalized
+	return nil
+}
+
+// normalizePriors validates the priors and scales them to sum to one
+func normalizePriors(priors map[string]float64) (map[string]float64, error) {
+	sum := 0.0
+	for _, p := range priors {
+		if p < 0 || math.IsNaN(p) || math.IsInf(p, 0) {
+			return nil, ErrInvalidPriors
+		}
+		sum += p
+	}
+	if sum <= 0 {
+		return nil, ErrInvalidPriors
+	}
+
+	normalized := make(map[string]float64, len(priors))
+	for category, p := range priors {
+		normalized[category] = p / sum
+	}
+	return normalized, nil
+}
+
+// logPrior returns the log prior probability of category
+func (c *Classifier) logPrior(category string) float64 {
+	switch c.prior {
+	case uniformPriors:
+		return -math.Log(float64(len(c.catCount)))
+	case fixedPriors:
+		return math.Log(c.priors[category])
+	default:
+		return math.Log(c.categoryCount(category) / float64(c.count()))
+	}
+}
+
+// normalize converts log scores into probabilities in place
+func normalize(scores []float64) {
+	max := math.Inf(-1)
+	for _, score := range scores {
+		max = math.Max(max, score)
+	}
+
+	sum := 0.0
+	for i, score := range scores {
+		if math.IsInf(max, -1) {
+			scores[i] = 0
+			continue
+		}
+		scores[i] = math.Exp(score - max)
+		sum += scores[i]
+	}
+	for i := range scores {
+		if sum > 0 {
+			scores[i] /= sum
+		}
+	}
+}
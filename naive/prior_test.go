@@ -0,0 +1,146 @@
+package naive
+
+import (
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func trainImbalanced(c *Classifier) {
+	for i := 0; i < 9; i++ {
+		c.TrainString("apple banana", "A")
+	}
+	c.TrainString("cherry banana", "X")
+}
+
+func TestPriors(t *testing.T) {
+	t.Run("Empirical", func(t *testing.T) {
+		classifier := New(Multinomial(1))
+		trainImbalanced(classifier)
+		if category, _ := classifier.ClassifyString("banana"); category != "A" {
+			t.Errorf("expected majority category A; got %q", category)
+		}
+	})
+
+	t.Run("Uniform", func(t *testing.T) {
+		classifier := New(Multinomial(1), UniformPriors())
+		trainImbalanced(classifier)
+		// P(banana|A) = (9+1)/(18+3) and P(banana|X) = (1+1)/(2+3)
+		expected := (10.0 / 21) / (10.0/21 + 2.0/5)
+		probabilities, _ := classifier.Probabilities("banana")
+		if math.Abs(probabilities["A"]-expected) > 1e-9 {
+			t.Errorf("expected P(A)=%.4f; got %.4f", expected, probabilities["A"])
+		}
+	})
+
+	t.Run("Fixed", func(t *testing.T) {
+		classifier := New(Multinomial(1), Priors(map[string]float64{"A": 1, "X": 99}))
+		trainImbalanced(classifier)
+		if category, _ := classifier.ClassifyString("banana"); category != "X" {
+			t.Errorf("expected category X; got %q", category)
+		}
+	})
+
+	t.Run("SetPriors", func(t *testing.T) {
+		classifier := New(Multinomial(1))
+		trainImbalanced(classifier)
+		if err := classifier.SetPriors(map[string]float64{"X": 1}); err != nil {
+			t.Fatal(err)
+		}
+		if category, _ := classifier.ClassifyString("apple"); category != "X" {
+			t.Errorf("expected category X; got %q", category)
+		}
+		if err := classifier.SetPriors(nil); err != nil {
+			t.Fatal(err)
+		}
+		if category, _ := classifier.ClassifyString("apple"); category != "A" {
+			t.Errorf("expected empirical priors to be restored; got %q", category)
+		}
+		if err := classifier.SetPriors(map[string]float64{"A": -1}); err != ErrInvalidPriors {
+			t.Errorf("expected invalid priors error; got %v", err)
+		}
+	})
+}
+
+func TestEstimatePriors(t *testing.T) {
+	classifier := New(Multinomial(1))
+	trainImbalanced(classifier)
+
+	var docs []string
+	for i := 0; i < 8; i++ {
+		docs = append(docs, "cherry")
+	}
+	docs = append(docs, "apple apple apple", "apple apple apple")
+
+	readers := make([]io.Reader, 0, len(docs))
+	for _, doc := range docs {
+		readers = append(readers, strings.NewReader(doc))
+	}
+
+	priors, err := classifier.EstimatePriors(readers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(priors["X"]-0.8) > 0.1 {
+		t.Errorf("expected X prior of approximately 0.8; got %.4f", priors["X"])
+	}
+	if category, _ := classifier.ClassifyString("banana"); category != "X" {
+		t.Errorf("expected estimated priors to favor X; got %q", category)
+	}
+
+	if _, err := New(Complement(1, false)).EstimatePriors(strings.NewReader("apple")); err == nil {
+		t.Error("expected an error for a model without priors")
+	}
+}
+
+func TestEstimatePriorsStream(t *testing.T) {
+	batch := New(Multinomial(1))
+	trainImbalanced(batch)
+	expected, err := batch.EstimatePriors(strings.NewReader("cherry"), strings.NewReader("apple apple apple"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	classifier := New(Multinomial(1))
+	trainImbalanced(classifier)
+	docs := make(chan io.Reader)
+	done := make(chan struct{})
+	var priors map[string]float64
+	go func() {
+		defer close(done)
+		priors, err = classifier.EstimatePriorsStream(docs)
+	}()
+
+	docs <- strings.NewReader("cherry")
+	// classification is not blocked while the stream is open
+	if _, err := classifier.ClassifyString("banana"); err != nil {
+		t.Fatal(err)
+	}
+	docs <- strings.NewReader("apple apple apple")
+	close(docs)
+	<-done
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	for category, p := range expected {
+		if math.Abs(priors[category]-p) > 1e-12 {
+			t.Errorf("expected %s prior of %.4f; got %.4f", category, p, priors[category])
+		}
+	}
+
+	docs = make(chan io.Reader)
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err = classifier.EstimatePriorsStream(docs)
+	}()
+	docs <- strings.NewReader("cherry")
+	classifier.TrainString("durian", "D")
+	close(docs)
+	<-done
+	if err != ErrCategoriesChanged {
+		t.Errorf("expected ErrCategoriesChanged; got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package naive
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrCannotUntrain indicates that removing a document would reduce the
+	// feature or category counts of the classifier below zero
+	ErrCannotUntrain = errors.New("document was not trained with the category")
+	// ErrUnknownDocument indicates that no identified document exists with the provided ID
+	ErrUnknownDocument = errors.New("unknown document")
+)
+
+// Untrain reverses a prior call to Train with the same document and category.
+// Features and categories whose counts reach zero are removed from the model.
+// If the document could not have been trained with the category, ErrCannotUntrain
+// is returned and the classifier is left unchanged. Identified documents must be
+// removed with Forget instead; counts that belong to identified documents cannot
+// be untrained.
+func (c *Classifier) Untrain(r io.Reader, category string) error {
+	features, err := c.features(context.Background(), r)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.unidentified(features, category) {
+		return ErrCannotUntrain
+	}
+	return c.untrain(features, category)
+}
+
+// UntrainString reverses a prior call to TrainString
+func (c *Classifier) UntrainString(doc string, category string) error {
+	return c.Untrain(asReader(doc), category)
+}
+
+// Forget removes an identified document that was trained with TrainDocument.
+// The document and its metadata are always removed; if its counts are no
+// longer part of the model, ErrCannotUntrain is returned after removing them.
+func (c *Classifier) Forget(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.docs[id]
+	if !ok {
+		return ErrUnknownDocument
+	}
+	err := c.untrain(e.Features, e.Category)
+	c.removeExample(id)
+	return err
+}
+
+// unidentified reports whether the counts of features within category can be
+// untrained without taking the counts of identified documents
+func (c *Classifier) unidentified(features map[string]int, category string) bool {
+	docs, tokens := 0, 0
+	for _, e := range c.docs {
+		if e.Category == category {
+			docs++
+			for _, count := range e.Features {
+				tokens += count
+			}
+		}
+	}
+	if c.catCount[category]-docs < 1 {
+		return false
+	}
+
+	total := 0
+	for feature, count := range features {
+		occurrences, documents := 0, 0
+		for id := range c.feat2ids[feature] {
+			if e := c.docs[id]; e.Category == category {
+				occurrences += e.Features[feature]
+				documents++
+			}
+		}
+		if c.feat2cat[feature][category]-occurrences < count || c.feat2doc[feature][category]-documents < 1 {
+			return false
+		}
+		total += count
+	}
+	return c.catTokens[category]-tokens >= total
+}
+
+func (c *Classifier) untrain(features map[string]int, category string) error {
+	tokens := 0
+	for feature, count := range features {
+		if c.feat2cat[feature][category] < count || c.feat2doc[feature][category] < 1 {
+			return ErrCannotUntrain
+		}
+		tokens += count
+	}
+	if c.catCount[category] < 1 || c.catTokens[category] < tokens {
+		return ErrCannotUntrain
+	}
+
+	for feature, count := range features {
+		decrement(c.feat2cat, feature, category, count)
+		decrement(c.feat2doc, feature, category, 1)
+	}
+	if c.catTokens[category] -= tokens; c.catTokens[category] == 0 {
+		delete(c.catTokens, category)
+	}
+	if c.catCount[category]--; c.catCount[category] == 0 {
+		delete(c.catCount, category)
+	}
+	c.bias.reset()
+	return nil
+}
+
+func (c *Classifier) removeExample(id string) {
+	for feature := range c.docs[id].Features {
+		delete(c.feat2ids[feature], id)
+		if len(c.feat2ids[feature]) == 0 {
+			delete(c.feat2ids, feature)
+		}
+	}
+	delete(c.docs, id)
+}
+
+// decrement reduces the count of feature within category, pruning empty entries
+func decrement(counts map[string]map[string]int, feature string, category string, count int) {
+	if counts[feature][category] -= count; counts[feature][category] == 0 {
+		delete(counts[feature], category)
+	}
+	if len(counts[feature]) == 0 {
+		delete(counts, feature)
+	}
+}
@@ -0,0 +1,118 @@
+package naive
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/n3integration/classifier"
+)
+
+func TestUntrain(t *testing.T) {
+	c := New()
+	c.TrainString(ham, "good")
+	c.TrainString(spam, "bad")
+	c.TrainString("online cash", "bad")
+
+	if err := c.UntrainString("online cash", "bad"); err != nil {
+		t.Fatal(err)
+	}
+	assertFeatureCount(t, c, "cash", "bad", 1)
+	assertCategoryCount(t, c, "bad", 1)
+
+	if err := c.UntrainString("online cash", "good"); err != ErrCannotUntrain {
+		t.Errorf("expected ErrCannotUntrain; got %v", err)
+	}
+	assertCategoryCount(t, c, "good", 1)
+
+	if err := c.UntrainString(spam, "bad"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.feat2cat["cash"]; ok {
+		t.Error("expected features with a zero count to be pruned")
+	}
+	if _, ok := c.catCount["bad"]; ok {
+		t.Error("expected categories with a zero count to be pruned")
+	}
+	if category, err := c.ClassifyString(spam); err != nil || category != "good" {
+		t.Errorf("expected the only remaining category; got %q (%v)", category, err)
+	}
+
+	if err := c.UntrainString(spam, "bad"); err != ErrCannotUntrain {
+		t.Errorf("expected ErrCannotUntrain; got %v", err)
+	}
+}
+
+func TestForget(t *testing.T) {
+	c := New()
+	c.TrainString(ham, "good")
+	before := New()
+	before.TrainString(ham, "good")
+
+	if err := c.TrainDocument(classifier.Document{ID: "1"}, strings.NewReader(spam), "bad"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Forget("1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Forget("1"); err != ErrUnknownDocument {
+		t.Errorf("expected ErrUnknownDocument; got %v", err)
+	}
+
+	if !reflect.DeepEqual(c.feat2cat, before.feat2cat) || !reflect.DeepEqual(c.catCount, before.catCount) ||
+		!reflect.DeepEqual(c.catTokens, before.catTokens) || !reflect.DeepEqual(c.feat2doc, before.feat2doc) {
+		t.Error("expected forgotten document to be removed from the model")
+	}
+	if len(c.docs) != 0 || len(c.feat2ids) != 0 {
+		t.Error("expected forgotten document bookkeeping to be removed")
+	}
+}
+
+func TestUntrainIdentified(t *testing.T) {
+	c := New()
+	c.TrainString(ham, "good")
+	if err := c.TrainDocument(classifier.Document{ID: "d1"}, strings.NewReader("apple banana"), "fruit"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.UntrainString("apple banana", "fruit"); err != ErrCannotUntrain {
+		t.Errorf("expected ErrCannotUntrain for an identified document; got %v", err)
+	}
+	assertCategoryCount(t, c, "fruit", 1)
+
+	c.TrainString("apple banana", "fruit")
+	if err := c.UntrainString("apple banana", "fruit"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Forget("d1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.catCount["fruit"]; ok {
+		t.Error("expected the forgotten document to be removed from the model")
+	}
+	if len(c.docs) != 0 || len(c.feat2ids) != 0 {
+		t.Error("expected forgotten document bookkeeping to be removed")
+	}
+}
+
+func TestForgetUntrained(t *testing.T) {
+	c := New()
+	c.TrainString(ham, "good")
+	if err := c.TrainDocument(classifier.Document{ID: "d1"}, strings.NewReader("apple banana"), "fruit"); err != nil {
+		t.Fatal(err)
+	}
+	// remove the counts without the bookkeeping, as an earlier release allowed
+	if err := c.untrain(c.docs["d1"].Features, "fruit"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Forget("d1"); err != ErrCannotUntrain {
+		t.Errorf("expected ErrCannotUntrain; got %v", err)
+	}
+	if len(c.docs) != 0 || len(c.feat2ids) != 0 {
+		t.Error("expected forgotten document bookkeeping to be removed")
+	}
+	if err := c.Forget("d1"); err != ErrUnknownDocument {
+		t.Errorf("expected ErrUnknownDocument; got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package classifier
+
+import (
+	"context"
+	"strings"
+)
+
+const defaultSeparator = " "
+
+// NGrams emits contiguous word n-grams of between min and max words, joined by
+// the separator, in place of single words. Unigrams are only emitted when min
+// is 1, so NGrams(1, 2) emits both words and word pairs. N-grams are formed
+// from the words that remain after filters and transforms are applied.
+func NGrams(min, max int) StdOption {
+	return func(t *StdTokenizer) {
+		if min < 1 {
+			min = 1
+		}
+		if max < min {
+			max = min
+		}
+		t.minN, t.maxN = min, max
+	}
+}
+
+// SkipGrams allows up to k words in total to be skipped between the words of
+// each n-gram of two or more words, which also emits k-skip-n-grams, such as
+// "not good" from "not very good" with k=1
+func SkipGrams(k int) StdOption {
+	return func(t *StdTokenizer) {
+		if k < 0 {
+			k = 0
+		}
+		t.skip = k
+	}
+}
+
+// Separator overrides the separator that joins the words of n-grams, which
+// defaults to a single space
+func Separator(sep string) StdOption {
+	return func(t *StdTokenizer) {
+		t.separator = sep
+	}
+}
+
+// grams reports whether the tokenizer emits anything other than unigrams
+func (t *StdTokenizer) grams() bool {
+	return t.maxN > 1
+}
+
+// nGrams emits the n-grams that end with each word of the input channel until
+// the context is cancelled
+func (t *StdTokenizer) nGrams(ctx context.Context, in chan string) chan string {
+	stream := make(chan string, defaultBufferSize)
+	window := make([]string, 0, t.maxN+t.skip)
+
+	go func() {
+		defer close(stream)
+		for word := range in {
+			if len(window) == cap(window) {
+				copy(window, window[1:])
+				window = window[:len(window)-1]
+			}
+			window = append(window, word)
+
+			for n := t.minN; n <= t.maxN; n++ {
+				for _, gram := range t.endingGrams(window, n) {
+					select {
+					case stream <- gram:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return stream
+}
+
+// endingGrams returns the n-grams that end with the last word of the window and
+// skip at most t.skip words in total
+func (t *StdTokenizer) endingGrams(window []string, n int) []string {
+	last := len(window) - 1
+	if n == 1 {
+		return []string{window[last]}
+	}
+
+	var grams []string
+	words := make([]string, n)
+	words[n-1] = window[last]
+
+	// choose the preceding words from right to left, consuming the skip budget
+	var choose func(slot, before, skip int)
+	choose = func(slot, before, skip int) {
+		if slot < 0 {
+			grams = append(grams, strings.Join(words, t.separator))
+			return
+		}
+		for gap := 0; gap <= skip; gap++ {
+			i := before - 1 - gap
+			if i < slot {
+				break
+			}
+			words[slot] = window[i]
+			choose(slot-1, i, skip-gap)
+		}
+	}
+	choose(n-2, last, t.skip)
+	return grams
+}
@@ -0,0 +1,95 @@
+package classifier
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNGrams(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Options  []StdOption
+		Text     string
+		Expected []string
+	}{
+		{
+			Name:     "Unigrams",
+			Options:  options(NGrams(1, 1)),
+			Text:     "not very good",
+			Expected: []string{"not", "very", "good"},
+		},
+		{
+			Name:     "Unigrams and Bigrams",
+			Options:  options(NGrams(1, 2)),
+			Text:     "Not very good",
+			Expected: []string{"not", "very", "not very", "good", "very good"},
+		},
+		{
+			Name:     "Bigrams and Trigrams",
+			Options:  options(NGrams(2, 3), Separator("_")),
+			Text:     "a b c d",
+			Expected: []string{"a_b", "b_c", "a_b_c", "c_d", "b_c_d"},
+		},
+		{
+			Name:     "Skip Grams",
+			Options:  options(NGrams(2, 2), SkipGrams(1)),
+			Text:     "not very good",
+			Expected: []string{"not very", "very good", "not good"},
+		},
+		{
+			Name:     "Skip Trigrams",
+			Options:  options(NGrams(3, 3), SkipGrams(1)),
+			Text:     "a b c d",
+			Expected: []string{"a b c", "b c d", "a c d", "a b d"},
+		},
+		{
+			Name:     "Invalid Range",
+			Options:  options(NGrams(0, -1)),
+			Text:     "a b",
+			Expected: []string{"a", "b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			tokenizer := NewTokenizer(append(test.Options, Filters())...)
+			actual := make([]string, 0)
+			for token := range tokenizer.Tokenize(strings.NewReader(test.Text)) {
+				actual = append(actual, token)
+			}
+			if !reflect.DeepEqual(actual, test.Expected) {
+				t.Errorf("expected %q; got %q", test.Expected, actual)
+			}
+		})
+	}
+}
+
+func TestNGramsFollowFilters(t *testing.T) {
+	tokenizer := NewTokenizer(NGrams(2, 2))
+	actual := make([]string, 0)
+	for token := range tokenizer.Tokenize(strings.NewReader("The quick brown fox")) {
+		actual = append(actual, token)
+	}
+	if expected := []string{"quick brown", "brown fox"}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q; got %q", expected, actual)
+	}
+}
+
+func TestNGramsFingerprint(t *testing.T) {
+	std := NewTokenizer().Fingerprint()
+	if std != NewTokenizer(NGrams(1, 1), Separator("_")).Fingerprint() {
+		t.Error("expected unigram settings to not change the fingerprint")
+	}
+
+	bigrams := NewTokenizer(NGrams(1, 2)).Fingerprint()
+	if bigrams == std {
+		t.Error("expected n-grams to change the fingerprint")
+	}
+	if bigrams == NewTokenizer(NGrams(1, 2), SkipGrams(1)).Fingerprint() {
+		t.Error("expected skip grams to change the fingerprint")
+	}
+	if bigrams == NewTokenizer(NGrams(1, 2), Separator("_")).Fingerprint() {
+		t.Error("expected the separator to change the fingerprint")
+	}
+}
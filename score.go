@@ -0,0 +1,78 @@
+package classifier
+
+import (
+	"errors"
+	"io"
+	"sort"
+)
+
+// ErrLowConfidence indicates that the most likely category did not satisfy the
+// configured confidence or margin thresholds
+var ErrLowConfidence = errors.New("classification confidence is below the configured threshold")
+
+// Score provides the score of a single category
+type Score struct {
+	Category string
+	Score    float64
+}
+
+// Scorer provides ranked category scores rather than a single classification
+type Scorer interface {
+	// Scores returns the score of every candidate category, ordered from the
+	// most to the least likely
+	Scores(io.Reader) ([]Score, error)
+	// ScoresString returns the ranked category scores of a string
+	ScoresString(string) ([]Score, error)
+}
+
+// ProbabilisticClassifier is a Classifier that also provides ranked category scores
+type ProbabilisticClassifier interface {
+	Classifier
+	Scorer
+}
+
+// SortScores orders scores from highest to lowest. Ties are broken by category
+// name, so that the order is deterministic.
+func SortScores(scores []Score) {
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Category < scores[j].Category
+	})
+}
+
+// Threshold provides the settings used to abstain from uncertain classifications
+type Threshold struct {
+	// MinConfidence is the minimum score required of the best category
+	MinConfidence float64
+	// MinMargin is the minimum difference required between the scores of the
+	// best and second best categories
+	MinMargin float64
+	// Fallback is returned in place of ErrLowConfidence when non-empty
+	Fallback string
+}
+
+// Decide returns the best category of the ranked scores, provided that it satisfies
+// the threshold. Otherwise, the fallback label is returned if configured, or
+// ErrLowConfidence if not.
+func (t Threshold) Decide(scores []Score) (string, error) {
+	if len(scores) > 0 && t.accept(scores) {
+		return scores[0].Category, nil
+	}
+	if t.Fallback != "" {
+		return t.Fallback, nil
+	}
+	return "", ErrLowConfidence
+}
+
+func (t Threshold) accept(scores []Score) bool {
+	if scores[0].Score < t.MinConfidence {
+		return false
+	}
+	margin := scores[0].Score
+	if len(scores) > 1 {
+		margin -= scores[1].Score
+	}
+	return margin >= t.MinMargin
+}
@@ -0,0 +1,48 @@
+package classifier
+
+import "testing"
+
+func TestSortScores(t *testing.T) {
+	scores := []Score{
+		{Category: "c", Score: 0.25},
+		{Category: "b", Score: 0.5},
+		{Category: "a", Score: 0.25},
+	}
+	SortScores(scores)
+
+	expected := []string{"b", "a", "c"}
+	for i, score := range scores {
+		if score.Category != expected[i] {
+			t.Errorf("expected %s at position %d; got %s", expected[i], i, score.Category)
+		}
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	scores := []Score{
+		{Category: "a", Score: 0.6},
+		{Category: "b", Score: 0.4},
+	}
+
+	tests := []struct {
+		Name      string
+		Threshold Threshold
+		Expected  string
+		Err       error
+	}{
+		{"Default", Threshold{}, "a", nil},
+		{"Confident", Threshold{MinConfidence: 0.5}, "a", nil},
+		{"Low Confidence", Threshold{MinConfidence: 0.7}, "", ErrLowConfidence},
+		{"Low Margin", Threshold{MinMargin: 0.25}, "", ErrLowConfidence},
+		{"Fallback", Threshold{MinMargin: 0.25, Fallback: "unknown"}, "unknown", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			category, err := test.Threshold.Decide(scores)
+			if category != test.Expected || err != test.Err {
+				t.Errorf("expected (%q, %v); got (%q, %v)", test.Expected, test.Err, category, err)
+			}
+		})
+	}
+}
@@ -2,10 +2,14 @@ package classifier
 
 import (
 	"bufio"
+	"context"
+	"fmt"
 	"io"
 	"strings"
 	"unicode"
 	"unicode/utf8"
+
+	"github.com/n3integration/classifier/internal/funcname"
 )
 
 // Tokenizer provides a common interface to tokenize documents
@@ -14,6 +18,83 @@ type Tokenizer interface {
 	Tokenize(io.Reader) chan string
 }
 
+// ContextTokenizer is implemented by Tokenizers that stop tokenizing once a
+// context is cancelled
+type ContextTokenizer interface {
+	Tokenizer
+	// TokenizeContext breaks the provided document into a channel of tokens,
+	// which is closed early if the context is cancelled
+	TokenizeContext(context.Context, io.Reader) chan string
+}
+
+// TokenizeContext tokenizes the document with t, closing the returned channel
+// early if the context is cancelled. Tokenizers that do not implement
+// ContextTokenizer are drained in the background after cancellation, so that
+// they are not blocked forever.
+func TokenizeContext(ctx context.Context, t Tokenizer, r io.Reader) chan string {
+	if ct, ok := t.(ContextTokenizer); ok {
+		return ct.TokenizeContext(ctx, r)
+	}
+
+	in := t.Tokenize(r)
+	tokens := make(chan string, defaultBufferSize)
+	go func() {
+		defer close(tokens)
+		for token := range in {
+			select {
+			case tokens <- token:
+			case <-ctx.Done():
+				go func() {
+					for range in {
+					}
+				}()
+				return
+			}
+		}
+	}()
+	return tokens
+}
+
+// CheckedTokenizer is implemented by Tokenizers that report the error of the
+// reader or scanner that ended tokenization, rather than silently truncating
+// the document
+type CheckedTokenizer interface {
+	Tokenizer
+	// TokenizeChecked breaks the provided document into a channel of tokens,
+	// which is closed early if the context is cancelled. Once the channel is
+	// closed, the returned function reports the error that ended tokenization,
+	// if any.
+	TokenizeChecked(context.Context, io.Reader) (chan string, func() error)
+}
+
+// TokenizeChecked tokenizes the document with t, closing the returned channel
+// early if the context is cancelled. Once the channel is closed, the returned
+// function reports the error of the context or, if t implements
+// CheckedTokenizer, the error of the reader or scanner.
+func TokenizeChecked(ctx context.Context, t Tokenizer, r io.Reader) (chan string, func() error) {
+	if ct, ok := t.(CheckedTokenizer); ok {
+		return ct.TokenizeChecked(ctx, r)
+	}
+	return TokenizeContext(ctx, t, r), ctx.Err
+}
+
+// Fingerprinter is implemented by Tokenizers that can describe their
+// configuration, which allows persisted models to detect that they are being
+// used with a different Tokenizer than the one they were trained with
+type Fingerprinter interface {
+	// Fingerprint returns a stable description of the tokenizer configuration
+	Fingerprint() string
+}
+
+// Fingerprint returns the fingerprint of t, or an empty string if t does not
+// implement Fingerprinter
+func Fingerprint(t Tokenizer) string {
+	if f, ok := t.(Fingerprinter); ok {
+		return f.Fingerprint()
+	}
+	return ""
+}
+
 // IsWord is a predicate to determine if a string contains at least two
 // characters and doesn't contain any numbers
 func IsWord(v string) bool {
@@ -26,10 +107,14 @@ type StdOption func(*StdTokenizer)
 // StdTokenizer provides a common document tokenizer that splits a
 // document by word boundaries
 type StdTokenizer struct {
-	transforms []Mapper
-	splitFn    bufio.SplitFunc
-	filters    []Predicate
-	bufferSize int
+	transforms   []Mapper
+	splitFn      bufio.SplitFunc
+	filters      []Predicate
+	bufferSize   int
+	maxTokenSize int
+	minN, maxN   int
+	skip         int
+	separator    string
 }
 
 // NewTokenizer initializes a new standard Tokenizer instance
@@ -37,6 +122,9 @@ func NewTokenizer(opts ...StdOption) *StdTokenizer {
 	tokenizer := &StdTokenizer{
 		bufferSize: 100,
 		splitFn:    bufio.ScanWords,
+		minN:       1,
+		maxN:       1,
+		separator:  defaultSeparator,
 		transforms: []Mapper{
 			strings.ToLower,
 		},
@@ -52,22 +140,88 @@ func NewTokenizer(opts ...StdOption) *StdTokenizer {
 
 // Tokenize words and return streaming results
 func (t *StdTokenizer) Tokenize(r io.Reader) chan string {
+	return t.TokenizeContext(context.Background(), r)
+}
+
+// TokenizeContext tokenizes words and returns streaming results until the
+// context is cancelled. The scanner stops before its next read of the reader
+// once the context is cancelled; a read that is already blocked is not
+// interrupted.
+func (t *StdTokenizer) TokenizeContext(ctx context.Context, r io.Reader) chan string {
+	tokens, _ := t.TokenizeChecked(ctx, r)
+	return tokens
+}
+
+// TokenizeChecked tokenizes words like TokenizeContext. Once the channel is
+// closed, the returned function reports the error of the context, the reader,
+// or the scanner, such as bufio.ErrTooLong for a token that exceeds the
+// maximum token size.
+func (t *StdTokenizer) TokenizeChecked(ctx context.Context, r io.Reader) (chan string, func() error) {
 	tokenizer := bufio.NewScanner(r)
 	tokenizer.Split(t.splitFn)
+	if t.maxTokenSize > 0 {
+		tokenizer.Buffer(make([]byte, 0, minInt(t.maxTokenSize, bufio.MaxScanTokenSize)), t.maxTokenSize)
+	}
 	tokens := make(chan string, t.bufferSize)
+	done := make(chan struct{})
+	var err error
 
 	go func() {
+		defer close(tokens)
+		defer close(done)
 		for tokenizer.Scan() {
-			tokens <- tokenizer.Text()
+			select {
+			case tokens <- tokenizer.Text():
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(tokens)
+		err = tokenizer.Err()
 	}()
 
-	return t.pipeline(tokens)
+	return t.pipeline(ctx, tokens), func() error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		<-done
+		return err
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (t *StdTokenizer) pipeline(ctx context.Context, in chan string) chan string {
+	words := MapContext(ctx, FilterContext(ctx, in, t.filters...), t.transforms...)
+	if !t.grams() {
+		return words
+	}
+	return t.nGrams(ctx, words)
 }
 
-func (t *StdTokenizer) pipeline(in chan string) chan string {
-	return Map(Filter(in, t.filters...), t.transforms...)
+// Fingerprint describes the split function, filters, transforms, and n-gram
+// settings of the tokenizer. Functions are described by name, and anonymous
+// functions are identified by their enclosing function, so the fingerprint is
+// stable across builds of the same program.
+func (t *StdTokenizer) Fingerprint() string {
+	filters := make([]string, len(t.filters))
+	for i, f := range t.filters {
+		filters[i] = funcname.Of(f)
+	}
+	transforms := make([]string, len(t.transforms))
+	for i, m := range t.transforms {
+		transforms[i] = funcname.Of(m)
+	}
+	fingerprint := fmt.Sprintf("std;split=%s;filters=%s;transforms=%s",
+		funcname.Of(t.splitFn), strings.Join(filters, ","), strings.Join(transforms, ","))
+	if t.grams() {
+		fingerprint += fmt.Sprintf(";ngrams=%d-%d;skip=%d;separator=%q", t.minN, t.maxN, t.skip, t.separator)
+	}
+	return fingerprint
 }
 
 // BufferSize adjusts the size of the buffered channel
@@ -77,6 +231,14 @@ func BufferSize(size int) StdOption {
 	}
 }
 
+// MaxTokenSize sets the maximum size of a token, which defaults to
+// bufio.MaxScanTokenSize. Longer tokens end tokenization with bufio.ErrTooLong.
+func MaxTokenSize(size int) StdOption {
+	return func(t *StdTokenizer) {
+		t.maxTokenSize = size
+	}
+}
+
 // SplitFunc overrides the default word split function, based on whitespace
 func SplitFunc(fn bufio.SplitFunc) StdOption {
 	return func(t *StdTokenizer) {
@@ -1,11 +1,16 @@
 package classifier
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"strings"
 	"testing"
 	"unicode"
+
+	"github.com/n3integration/classifier/internal/leaktest"
 )
 
 var (
@@ -35,6 +40,94 @@ func TestTokenize(t *testing.T) {
 	}
 }
 
+func TestFingerprint(t *testing.T) {
+	std := NewTokenizer().Fingerprint()
+	if std != NewTokenizer().Fingerprint() {
+		t.Error("expected identical tokenizers to share a fingerprint")
+	}
+	if !strings.Contains(std, "IsNotStopWord") || !strings.Contains(std, "ToLower") {
+		t.Errorf("expected fingerprint to describe filters and transforms; got %s", std)
+	}
+	if std == NewTokenizer(SplitFunc(ScanAlphaWords)).Fingerprint() {
+		t.Error("expected split function to change the fingerprint")
+	}
+	if std == NewTokenizer(Filters(IsWord)).Fingerprint() {
+		t.Error("expected filters to change the fingerprint")
+	}
+	if std != Fingerprint(NewTokenizer(BufferSize(1))) {
+		t.Error("expected buffer size to not change the fingerprint")
+	}
+}
+
+func TestTokenizeContext(t *testing.T) {
+	defer leaktest.Check(t)()
+
+	tokenizers := []Tokenizer{
+		NewTokenizer(),
+		NewTokenizer(BufferSize(0)),
+		NewTokenizer(NGrams(1, 3), SkipGrams(2)),
+		tokenizerFunc(NewTokenizer().Tokenize),
+	}
+	for _, tokenizer := range tokenizers {
+		ctx, cancel := context.WithCancel(context.Background())
+		tokens := TokenizeContext(ctx, tokenizer, io.LimitReader(leaktest.Endless{}, 1<<20))
+		for i := 0; i < 10; i++ {
+			if token := <-tokens; !strings.HasPrefix(token, "word") {
+				t.Fatalf("expected words; got %q", token)
+			}
+		}
+		cancel()
+		for range tokens {
+		}
+	}
+}
+
+func TestTokenizeChecked(t *testing.T) {
+	errRead := errors.New("read failed")
+	tokens, wait := TokenizeChecked(context.Background(), NewTokenizer(), io.MultiReader(toReader(text), failingReader{errRead}))
+	count := 0
+	for range tokens {
+		count++
+	}
+	if err := wait(); err != errRead {
+		t.Errorf("expected the error of the reader; got %v", err)
+	}
+	if count != expected {
+		t.Errorf("expected %d tokens before the error; got %d", expected, count)
+	}
+
+	long := text + " " + strings.Repeat("x", 100)
+	tokens, wait = TokenizeChecked(context.Background(), NewTokenizer(MaxTokenSize(64)), toReader(long))
+	for range tokens {
+	}
+	if err := wait(); err != bufio.ErrTooLong {
+		t.Errorf("expected bufio.ErrTooLong; got %v", err)
+	}
+
+	tokens, wait = TokenizeChecked(context.Background(), NewTokenizer(MaxTokenSize(128)), toReader(long))
+	for range tokens {
+	}
+	if err := wait(); err != nil {
+		t.Errorf("expected tokens within the maximum size; got %v", err)
+	}
+}
+
+// failingReader fails every read with err
+type failingReader struct {
+	err error
+}
+
+func (r failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// tokenizerFunc adapts a function to a Tokenizer that does not observe contexts
+type tokenizerFunc func(io.Reader) chan string
+
+func (f tokenizerFunc) Tokenize(r io.Reader) chan string {
+	return f(r)
+}
+
 func isStopWord(t *testing.T, v string) {
 	if IsStopWord(v) {
 		t.Errorf("value is a stopword")